@@ -0,0 +1,208 @@
+package argoparser
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"unicode/utf8"
+)
+
+// constraints are declarative restrictions of the field value from tags
+// min, max, minlen, maxlen, pattern, mincount, maxcount and nonempty option
+type constraints struct {
+	min string
+	max string
+	// minValue and maxValue are min and max converted to the type of the value
+	minValue reflect.Value
+	maxValue reflect.Value
+	minLen   int
+	maxLen   int
+	pattern  *regexp.Regexp
+	nonEmpty bool
+	minCount int
+	maxCount int
+}
+
+// getConstraints reads constraint tags and validates they are applicable to the type
+func getConstraints(field reflect.StructField, nonEmpty bool) (constraints, error) {
+	c := constraints{
+		min:      field.Tag.Get("min"),
+		max:      field.Tag.Get("max"),
+		minLen:   -1,
+		maxLen:   -1,
+		nonEmpty: nonEmpty,
+		minCount: -1,
+		maxCount: -1,
+	}
+
+	intTags := []struct {
+		name   string
+		target *int
+	}{
+		{name: "minlen", target: &c.minLen},
+		{name: "maxlen", target: &c.maxLen},
+		{name: "mincount", target: &c.minCount},
+		{name: "maxcount", target: &c.maxCount},
+	}
+	for _, tag := range intTags {
+		value, ok := field.Tag.Lookup(tag.name)
+		if !ok {
+			continue
+		}
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			return constraints{}, fmt.Errorf("invalid %s tag: %s", tag.name, value)
+		}
+		*tag.target = parsed
+	}
+
+	if pattern, ok := field.Tag.Lookup("pattern"); ok {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return constraints{}, fmt.Errorf("invalid pattern tag: %w", err)
+		}
+		c.pattern = compiled
+	}
+
+	t, isContainer := constrainedType(field.Type)
+
+	if (c.min != "" || c.max != "") && !isNumberKind(t.Kind()) {
+		return constraints{}, errors.New("min and max tags are not applicable to the type")
+	}
+	if (c.minLen >= 0 || c.maxLen >= 0 || c.pattern != nil || c.nonEmpty) && t.Kind() != reflect.String {
+		return constraints{}, errors.New("minlen, maxlen, pattern and nonempty are not applicable to the type")
+	}
+	if (c.minCount >= 0 || c.maxCount >= 0) && !isContainer {
+		return constraints{}, errors.New("mincount and maxcount tags are not applicable to the type")
+	}
+
+	return c, nil
+}
+
+// constrainedType returns the type constraints are checked for: the type of
+// items of slices and values of maps, without pointer
+func constrainedType(t reflect.Type) (reflect.Type, bool) {
+	isContainer := t.Kind() == reflect.Slice || t.Kind() == reflect.Map
+	if isContainer {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t, isContainer
+}
+
+// convertBounds converts min and max tags to the type of the value, so they
+// may be written the same way as the value, e.g. min:"1s" for time.Duration
+func (p *Parser) convertBounds(entry *indexEntry) error {
+	c := &entry.m.constraints
+	t, _ := constrainedType(entry.t)
+
+	bounds := []struct {
+		name   string
+		value  string
+		target *reflect.Value
+	}{
+		{name: "min", value: c.min, target: &c.minValue},
+		{name: "max", value: c.max, target: &c.maxValue},
+	}
+	for _, bound := range bounds {
+		if bound.value == "" {
+			continue
+		}
+		converted := reflect.New(t).Elem()
+		if err := p.setValue(converted, bound.value, entry.m); err != nil {
+			return &DefinitionError{Field: entry.path, Err: fmt.Errorf("invalid %s tag: %w", bound.name, err)}
+		}
+		*bound.target = converted
+	}
+	return nil
+}
+
+func isNumberKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// checkConstraints validates converted value of the field or of its item and
+// returns the violated constraint
+func checkConstraints(meta fieldMeta, v reflect.Value) error {
+	c := meta.constraints
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	if c.minValue.IsValid() && compareBound(v, c.minValue) < 0 {
+		return fmt.Errorf("%s is less than min %s", formatValue(v), c.min)
+	}
+	if c.maxValue.IsValid() && compareBound(v, c.maxValue) > 0 {
+		return fmt.Errorf("%s is greater than max %s", formatValue(v), c.max)
+	}
+
+	if v.Kind() == reflect.String {
+		value := v.String()
+		length := utf8.RuneCountInString(value)
+		if c.nonEmpty && value == "" {
+			return errors.New("value must not be empty")
+		}
+		if c.minLen >= 0 && length < c.minLen {
+			return fmt.Errorf("length %d is less than minlen %d", length, c.minLen)
+		}
+		if c.maxLen >= 0 && length > c.maxLen {
+			return fmt.Errorf("length %d is greater than maxlen %d", length, c.maxLen)
+		}
+		if c.pattern != nil && !c.pattern.MatchString(value) {
+			return fmt.Errorf("%q does not match pattern %s", value, c.pattern)
+		}
+	}
+
+	return nil
+}
+
+// compareBound returns -1, 0 or 1 if the value is less, equal or greater than the bound
+func compareBound(v reflect.Value, bound reflect.Value) int {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cmp.Compare(v.Int(), bound.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return cmp.Compare(v.Uint(), bound.Uint())
+	}
+	return cmp.Compare(v.Float(), bound.Float())
+}
+
+// checkCount validates the number of items of slice or map field
+func checkCount(entry *indexEntry) error {
+	c := entry.m.constraints
+	if c.minCount < 0 && c.maxCount < 0 {
+		return nil
+	}
+
+	count := entry.v.Len()
+	var err error
+	if c.minCount >= 0 && count < c.minCount {
+		err = fmt.Errorf("number of values %d is less than mincount %d", count, c.minCount)
+	} else if c.maxCount >= 0 && count > c.maxCount {
+		err = fmt.Errorf("number of values %d is greater than maxcount %d", count, c.maxCount)
+	} else {
+		return nil
+	}
+
+	return &InvalidValueError{
+		Field:    entry.path,
+		Option:   entry.m.displayName(),
+		Position: -1,
+		Err:      err,
+		Location: noLocation,
+	}
+}
@@ -0,0 +1,206 @@
+package argoparser
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConstraints(t *testing.T) {
+	tc := []TestCase{
+		{
+			Name:  "Values within constraints",
+			Input: "--limit 10 --ratio 0.5 --timeout 2s --name abc --id ab-12 --tag x --tag y --label a=1 prod",
+			Result: struct {
+				Limit   int            `arg:"--limit" min:"1" max:"100"`
+				Ratio   float64        `arg:"--ratio" min:"0" max:"1"`
+				Timeout time.Duration  `arg:"--timeout" min:"1s" max:"1m"`
+				Name    string         `arg:"--name,nonempty" minlen:"2" maxlen:"5"`
+				ID      string         `arg:"--id" pattern:"^[a-z]+-[0-9]+$"`
+				Tags    []string       `arg:"--tag" mincount:"1" maxcount:"3"`
+				Labels  map[string]int `arg:"--label" max:"5" maxcount:"1"`
+				Target  string         `arg:"positional" minlen:"3"`
+			}{
+				Limit: 10, Ratio: 0.5, Timeout: 2 * time.Second, Name: "abc", ID: "ab-12",
+				Tags: []string{"x", "y"}, Labels: map[string]int{"a": 1}, Target: "prod",
+			},
+		},
+		{
+			Name:  "Less than min",
+			Input: "--limit 0",
+			Result: struct {
+				Limit int `arg:"--limit" min:"1"`
+			}{},
+			ShouldReturnError: true,
+		},
+		{
+			Name:  "Greater than max",
+			Input: "--timeout 2m",
+			Result: struct {
+				Timeout time.Duration `arg:"--timeout" max:"1m"`
+			}{},
+			ShouldReturnError: true,
+		},
+		{
+			Name:  "Unsigned bounds",
+			Input: "--port 80",
+			Result: struct {
+				Port uint16 `arg:"--port" min:"1024"`
+			}{},
+			ShouldReturnError: true,
+		},
+		{
+			Name:  "Pointer field",
+			Input: "--limit 200",
+			Result: struct {
+				Limit *int `arg:"--limit" max:"100"`
+			}{},
+			ShouldReturnError: true,
+		},
+		{
+			Name:  "Empty value",
+			Input: `--name ""`,
+			Result: struct {
+				Name string `arg:"--name,nonempty"`
+			}{},
+			ShouldReturnError: true,
+		},
+		{
+			Name:  "Length is counted in runes",
+			Input: "--name привет",
+			Result: struct {
+				Name string `arg:"--name" maxlen:"6"`
+			}{Name: "привет"},
+		},
+		{
+			Name:  "Too long",
+			Input: "--name abcdef",
+			Result: struct {
+				Name string `arg:"--name" maxlen:"5"`
+			}{},
+			ShouldReturnError: true,
+		},
+		{
+			Name:  "Pattern mismatch",
+			Input: "--id AB-12",
+			Result: struct {
+				ID string `arg:"--id" pattern:"^[a-z]+-[0-9]+$"`
+			}{},
+			ShouldReturnError: true,
+		},
+		{
+			Name:  "Slice item is checked",
+			Input: "--port 80 --port 0",
+			Result: struct {
+				Ports []int `arg:"--port" min:"1"`
+			}{},
+			ShouldReturnError: true,
+		},
+		{
+			Name:  "Map value is checked",
+			Input: "--label a=10",
+			Result: struct {
+				Labels map[string]int `arg:"--label" max:"5"`
+			}{},
+			ShouldReturnError: true,
+		},
+		{
+			Name:  "Too few items",
+			Input: "--tag x",
+			Result: struct {
+				Tags []string `arg:"--tag" mincount:"2"`
+			}{},
+			ShouldReturnError: true,
+		},
+		{
+			Name:  "Too many items",
+			Input: "--tag x --tag y --tag z",
+			Result: struct {
+				Tags []string `arg:"--tag" maxcount:"2"`
+			}{},
+			ShouldReturnError: true,
+		},
+		{
+			Name:  "Count is not checked for missing field",
+			Input: "",
+			Result: struct {
+				Tags []string `arg:"--tag" mincount:"2"`
+			}{Tags: []string{}},
+		},
+		{
+			Name:  "Default value is checked",
+			Input: "",
+			Result: struct {
+				Limit int `arg:"--limit" min:"1" default:"0"`
+			}{},
+			ShouldReturnError: true,
+		},
+		{
+			Name:  "Min is not applicable to string",
+			Input: "",
+			Result: struct {
+				Name string `arg:"--name" min:"1"`
+			}{},
+			ShouldReturnError: true,
+		},
+		{
+			Name:  "Pattern is not applicable to int",
+			Input: "",
+			Result: struct {
+				Limit int `arg:"--limit" pattern:"^1"`
+			}{},
+			ShouldReturnError: true,
+		},
+		{
+			Name:  "Mincount is not applicable to scalar",
+			Input: "",
+			Result: struct {
+				Limit int `arg:"--limit" mincount:"1"`
+			}{},
+			ShouldReturnError: true,
+		},
+		{
+			Name:  "Invalid pattern",
+			Input: "",
+			Result: struct {
+				ID string `arg:"--id" pattern:"[a-"`
+			}{},
+			ShouldReturnError: true,
+		},
+		{
+			Name:  "Invalid bound",
+			Input: "",
+			Result: struct {
+				Limit int `arg:"--limit" min:"one"`
+			}{},
+			ShouldReturnError: true,
+		},
+	}
+
+	for _, testCase := range tc {
+		impl(t, testCase, false)
+	}
+
+	t.Run("Errors name the flag and the constraint", func(t *testing.T) {
+		result := struct {
+			Limit int      `arg:"--limit" min:"1" max:"100"`
+			Name  string   `arg:"--name" maxlen:"3"`
+			ID    string   `arg:"--id" pattern:"^[a-z]+$"`
+			Tags  []string `arg:"--tag" mincount:"2"`
+		}{}
+		parser := Parser{}
+
+		cases := map[string]string{
+			"--limit 0":   "invalid value for --limit: 0 is less than min 1",
+			"--limit 101": "invalid value for --limit: 101 is greater than max 100",
+			"--name abcd": "invalid value for --name: length 4 is greater than maxlen 3",
+			"--id a1":     `invalid value for --id: "a1" does not match pattern ^[a-z]+$`,
+			"--tag x":     "invalid value for --tag: number of values 1 is less than mincount 2",
+		}
+		for input, expected := range cases {
+			err := parser.ParseString(input, &result)
+			if err == nil || err.Error() != expected {
+				t.Fatalf("unexpected error for %q: %v", input, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,227 @@
+package argoparser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Location is the place of the offending token in the input. For ParseSlice
+// and ParseAppArgs it's the place in the arguments joined by spaces.
+type Location struct {
+	// Offset is the rune offset of the token, -1 if the error is not related to the input
+	Offset int
+	// Length of the token in runes, quotes included
+	Length int
+}
+
+func (l *Location) location() Location {
+	return *l
+}
+
+func (l *Location) setLocation(t token) {
+	l.Offset = t.Offset
+	l.Length = t.End - t.Offset
+}
+
+// noLocation is the location of values from environment variables
+var noLocation = Location{Offset: -1}
+
+// locateError sets the location of the error to the offending token. Tokens
+// are the key and the tokens consumed with it, the value is the last one.
+func locateError(err error, tokens []token) {
+	var invalid *InvalidValueError
+	if errors.As(err, &invalid) {
+		invalid.setLocation(tokens[len(tokens)-1])
+		return
+	}
+	var located interface{ setLocation(token) }
+	if errors.As(err, &located) {
+		located.setLocation(tokens[0])
+	}
+}
+
+// UnknownOptionError is returned for a key which doesn't match any field, or
+// for a positional argument when all positional fields are already filled
+type UnknownOptionError struct {
+	// Key as it was passed: --limt, -x. Empty for positional arguments.
+	Key string
+	// Value is the unexpected positional argument
+	Value string
+	// Position is the index of the token in the input
+	Position int
+	// Suggestion is the closest known key, or command for positional arguments.
+	// Empty if nothing is close enough.
+	Suggestion string
+	// Location of the key or the positional argument
+	Location
+}
+
+func (e *UnknownOptionError) Error() string {
+	var message string
+	if e.Key == "" {
+		message = fmt.Sprintf("unexpected positional parameter: %s", e.Value)
+	} else if strings.HasPrefix(e.Key, "--") {
+		message = fmt.Sprintf("unknown long key: %s", e.Key)
+	} else {
+		message = fmt.Sprintf("unknown short key: %s", e.Key)
+	}
+
+	if e.Suggestion != "" {
+		message += fmt.Sprintf(", did you mean %s?", e.Suggestion)
+	}
+	return message
+}
+
+// SyntaxError is returned when the input string can't be split into tokens:
+// the quote is not closed or the backslash is the last rune of the input
+type SyntaxError struct {
+	Message string
+	// Location from the opening quote to the end of the input, or of the backslash
+	Location
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error: %s", e.Message)
+}
+
+// MissingValueError is returned when the key of non-flag field is the last one in the input
+type MissingValueError struct {
+	// Field is the path of the field in the struct: Limit, Subscriptions.Get.UserID
+	Field    string
+	Key      string
+	Position int
+	// Location of the key
+	Location
+}
+
+func (e *MissingValueError) Error() string {
+	return fmt.Sprintf("missing value for flag: %s", e.Key)
+}
+
+// InvalidValueError is returned when the value cannot be converted to the type
+// of the field or doesn't satisfy its choices and constraints
+type InvalidValueError struct {
+	Field string
+	// Option is the name of the argument in messages: --limit, or module for positional ones
+	Option string
+	// Key as it was passed: -l. Name of the environment variable for values
+	// from environment, empty for positional arguments.
+	Key   string
+	Value string
+	// Position is the index of the token in the input, -1 for values not from the input
+	Position int
+	// Err is the cause: conversion error or violated constraint
+	Err error
+	// Location of the value, which is the same token as the key for -l10
+	Location
+}
+
+func (e *InvalidValueError) Error() string {
+	return fmt.Sprintf("invalid value for %s: %v", e.Option, e.Err)
+}
+
+func (e *InvalidValueError) Unwrap() error {
+	return e.Err
+}
+
+// MissingRequiredError is returned when the required field is not passed,
+// or when the field is required by another one with requires or and tag
+type MissingRequiredError struct {
+	Field  string
+	Option string
+	// RequiredBy is the option which requires this one, if any
+	RequiredBy string
+	// Group is the name of and group, if the option is required by it
+	Group string
+}
+
+func (e *MissingRequiredError) Error() string {
+	switch {
+	case e.Group != "":
+		return fmt.Sprintf("options of group %s must be passed together: %s is passed without %s", e.Group, e.RequiredBy, e.Option)
+	case e.RequiredBy != "":
+		return fmt.Sprintf("option %s requires %s", e.RequiredBy, e.Option)
+	case strings.HasPrefix(e.Option, "-"):
+		return fmt.Sprintf("missing required option: %s", e.Option)
+	}
+	return fmt.Sprintf("missing required argument: %s", e.Option)
+}
+
+// ConflictError is returned when options which exclude each other are passed
+// together: --flag and --no-flag, options of xor group or with conflicts tag
+type ConflictError struct {
+	Options []string
+	// Group is the name of xor group, if the options belong to it
+	Group string
+}
+
+func (e *ConflictError) Error() string {
+	if e.Group != "" {
+		return fmt.Sprintf("conflicting options in group %s: %s", e.Group, strings.Join(e.Options, " and "))
+	}
+	return fmt.Sprintf("conflicting options: %s", strings.Join(e.Options, " and "))
+}
+
+// DefinitionError is returned for invalid struct: unsupported types,
+// malformed tags, duplicate keys and so on
+type DefinitionError struct {
+	// Field is the path of the invalid field, empty for errors of the struct itself
+	Field string
+	Err   error
+}
+
+func (e *DefinitionError) Error() string {
+	if e.Field == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("invalid field %s: %v", e.Field, e.Err)
+}
+
+func (e *DefinitionError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorList is returned in CollectErrors mode and holds all errors of parsing
+// in the order they were met. It works with errors.Is and errors.As like the
+// result of errors.Join.
+type ErrorList []error
+
+func (e ErrorList) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "\n")
+}
+
+func (e ErrorList) Unwrap() []error {
+	return e
+}
+
+// errorCollector gathers errors of parsing. Without CollectErrors parsing
+// stops on the first error, so later errors are dropped.
+type errorCollector struct {
+	collect bool
+	errs    []error
+}
+
+// add gathers the error and reports whether parsing may go on.
+// Errors of the struct definition always stop parsing.
+func (c *errorCollector) add(err error) bool {
+	if len(c.errs) == 0 || c.collect {
+		c.errs = append(c.errs, err)
+	}
+	var definitionErr *DefinitionError
+	return c.collect && !errors.As(err, &definitionErr)
+}
+
+func (c *errorCollector) result() error {
+	if len(c.errs) == 0 {
+		return nil
+	}
+	if !c.collect {
+		return c.errs[0]
+	}
+	return ErrorList(c.errs)
+}
@@ -0,0 +1,356 @@
+package argoparser
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type testErrorsGetCmd struct {
+	UserID int `arg:"--user-id,-u,required"`
+}
+
+type testErrorsArgs struct {
+	Limit  int               `arg:"--limit,-l" max:"100"`
+	Color  bool              `arg:"--color,negatable"`
+	Module string            `arg:"positional,required"`
+	Get    *testErrorsGetCmd `cmd:"get"`
+}
+
+func TestErrorTypes(t *testing.T) {
+	parser := Parser{}
+
+	t.Run("Unknown long key", func(t *testing.T) {
+		err := parser.ParseString("core --limt 10", &testErrorsArgs{})
+		var target *UnknownOptionError
+		if !errors.As(err, &target) {
+			t.Fatalf("expected UnknownOptionError, got %v", err)
+		}
+		expected := UnknownOptionError{
+			Key:        "--limt",
+			Position:   1,
+			Suggestion: "--limit",
+			Location:   Location{Offset: 5, Length: 6},
+		}
+		if *target != expected {
+			t.Fatalf("expected %+v, got %+v", expected, *target)
+		}
+		if err.Error() != "unknown long key: --limt, did you mean --limit?" {
+			t.Fatalf("unexpected message: %s", err)
+		}
+	})
+
+	t.Run("Unknown short key in group", func(t *testing.T) {
+		err := parser.ParseString("core -xl 10", &testErrorsArgs{})
+		var target *UnknownOptionError
+		if !errors.As(err, &target) || target.Key != "-x" || target.Position != 1 {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+
+	t.Run("Unexpected positional argument", func(t *testing.T) {
+		err := parser.ParseString("core extra", &testErrorsArgs{})
+		var target *UnknownOptionError
+		if !errors.As(err, &target) || target.Key != "" || target.Value != "extra" || target.Position != 1 {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+		if err.Error() != "unexpected positional parameter: extra" {
+			t.Fatalf("unexpected message: %s", err)
+		}
+	})
+
+	t.Run("Missing value", func(t *testing.T) {
+		err := parser.ParseString("core -l", &testErrorsArgs{})
+		var target *MissingValueError
+		if !errors.As(err, &target) {
+			t.Fatalf("expected MissingValueError, got %v", err)
+		}
+		expected := MissingValueError{
+			Field:    "Limit",
+			Key:      "-l",
+			Position: 1,
+			Location: Location{Offset: 5, Length: 2},
+		}
+		if *target != expected {
+			t.Fatalf("expected %+v, got %+v", expected, *target)
+		}
+	})
+
+	t.Run("Invalid value wraps the cause", func(t *testing.T) {
+		err := parser.ParseString("core -l ten", &testErrorsArgs{})
+		var target *InvalidValueError
+		if !errors.As(err, &target) {
+			t.Fatalf("expected InvalidValueError, got %v", err)
+		}
+		if target.Field != "Limit" || target.Option != "--limit" || target.Key != "-l" ||
+			target.Value != "ten" || target.Position != 1 || target.Err == nil {
+			t.Fatalf("unexpected error: %+v", *target)
+		}
+		if err.Error() != `invalid value for --limit: "ten" is not a valid int` {
+			t.Fatalf("unexpected message: %s", err)
+		}
+	})
+
+	t.Run("Constraint violation", func(t *testing.T) {
+		err := parser.ParseString("core --limit=200", &testErrorsArgs{})
+		var target *InvalidValueError
+		if !errors.As(err, &target) || target.Value != "200" || target.Key != "--limit" {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+
+	t.Run("Custom converter error is reachable", func(t *testing.T) {
+		parser := Parser{}
+		parser.RegisterType(reflect.TypeOf(0), func(value string) (any, error) {
+			return strconv.Atoi(value)
+		})
+		err := parser.ParseString("core -l ten", &testErrorsArgs{})
+		var numErr *strconv.NumError
+		if !errors.As(err, &numErr) {
+			t.Fatalf("expected strconv.NumError, got %v", err)
+		}
+	})
+
+	t.Run("Invalid value in environment variable", func(t *testing.T) {
+		os.Setenv("ARGO_TEST_LIMIT", "ten")
+		defer os.Unsetenv("ARGO_TEST_LIMIT")
+
+		result := struct {
+			Limit int `arg:"--limit" env:"ARGO_TEST_LIMIT"`
+		}{}
+		err := parser.ParseString("", &result)
+		var target *InvalidValueError
+		if !errors.As(err, &target) || target.Key != "ARGO_TEST_LIMIT" || target.Position != -1 {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+	})
+
+	t.Run("Missing required option of command", func(t *testing.T) {
+		err := parser.ParseString("core get", &testErrorsArgs{})
+		var target *MissingRequiredError
+		if !errors.As(err, &target) {
+			t.Fatalf("expected MissingRequiredError, got %v", err)
+		}
+		expected := MissingRequiredError{Field: "Get.UserID", Option: "--user-id"}
+		if *target != expected {
+			t.Fatalf("expected %+v, got %+v", expected, *target)
+		}
+		if err.Error() != "missing required option: --user-id" {
+			t.Fatalf("unexpected message: %s", err)
+		}
+	})
+
+	t.Run("Missing required positional argument", func(t *testing.T) {
+		err := parser.ParseString("", &testErrorsArgs{})
+		var target *MissingRequiredError
+		if !errors.As(err, &target) || target.Field != "Module" {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+		if err.Error() != "missing required argument: module" {
+			t.Fatalf("unexpected message: %s", err)
+		}
+	})
+
+	t.Run("Conflicting flags", func(t *testing.T) {
+		err := parser.ParseString("core --color --no-color", &testErrorsArgs{})
+		var target *ConflictError
+		if !errors.As(err, &target) || !reflect.DeepEqual(target.Options, []string{"--color", "--no-color"}) {
+			t.Fatalf("unexpected error: %#v", err)
+		}
+		if err.Error() != "conflicting options: --color and --no-color" {
+			t.Fatalf("unexpected message: %s", err)
+		}
+	})
+
+	t.Run("Definition errors", func(t *testing.T) {
+		cases := []struct {
+			input   string
+			result  any
+			field   string
+			message string
+		}{
+			{
+				result: &struct {
+					Limit int `arg:"--limit,count,-l,bad"`
+				}{},
+				field:   "Limit",
+				message: "invalid field Limit: invalid arg tag: bad",
+			},
+			{
+				result:  struct{}{},
+				message: "input must be a pointer",
+			},
+			{
+				result: &struct {
+					Get *testErrorsGetCmd `cmd:"get" arg:"--get"`
+				}{},
+				field:   "Get",
+				message: "invalid field Get: command field cannot have arg tag",
+			},
+			{
+				input: "--point 1",
+				result: &struct {
+					Point struct{ X int } `arg:"--point"`
+				}{},
+				field:   "Point",
+				message: "invalid field Point: unsupported type: struct { X int }",
+			},
+			{
+				result: &struct {
+					Limit int `arg:"--limit" min:"abc"`
+				}{},
+				field:   "Limit",
+				message: `invalid field Limit: invalid min tag: "abc" is not a valid int`,
+			},
+			{
+				result: &struct {
+					Timeouts []time.Duration `arg:"--timeout" max:"1 minute"`
+				}{},
+				field:   "Timeouts",
+				message: `invalid field Timeouts: invalid max tag: cannot convert "1 minute" to time.Duration: time: unknown unit " minute" in duration "1 minute"`,
+			},
+			{
+				input: "--limit 3",
+				result: &struct {
+					Limit int `arg:"--limit" default:"ten"`
+				}{},
+				field:   "Limit",
+				message: `invalid field Limit: invalid default value: "ten" is not a valid int`,
+			},
+			{
+				result: &struct {
+					Env string `arg:"--env" choices:"testing|production" default:"staging"`
+				}{},
+				field:   "Env",
+				message: `invalid field Env: invalid default value: "staging" is not one of testing|production`,
+			},
+		}
+
+		for _, c := range cases {
+			err := parser.ParseString(c.input, c.result)
+			var target *DefinitionError
+			if !errors.As(err, &target) || target.Field != c.field {
+				t.Fatalf("unexpected error: %#v", err)
+			}
+			if err.Error() != c.message {
+				t.Fatalf("unexpected message: %s", err)
+			}
+		}
+	})
+}
+
+func TestCollectErrors(t *testing.T) {
+	type args struct {
+		Limit  int    `arg:"--limit,required"`
+		Env    string `arg:"--env"`
+		User   string `arg:"--user,required"`
+		Module string `arg:"positional"`
+	}
+
+	t.Run("All errors are returned", func(t *testing.T) {
+		parser := Parser{CollectErrors: true}
+		err := parser.ParseString("--limit abc --region eu core extra --env", &args{})
+
+		var list ErrorList
+		if !errors.As(err, &list) {
+			t.Fatalf("expected ErrorList, got %v", err)
+		}
+		expected := []string{
+			`invalid value for --limit: "abc" is not a valid int`,
+			"unknown long key: --region",
+			"unexpected positional parameter: core",
+			"unexpected positional parameter: extra",
+			"missing value for flag: --env",
+			"missing required option: --user",
+		}
+		if len(list) != len(expected) {
+			t.Fatalf("expected %d errors, got %d:\n%s", len(expected), len(list), err)
+		}
+		for i := range expected {
+			if list[i].Error() != expected[i] {
+				t.Fatalf("expected %q, got %q", expected[i], list[i])
+			}
+		}
+
+		var missing *MissingRequiredError
+		if !errors.As(err, &missing) || missing.Field != "User" {
+			t.Fatalf("errors.As must find the error in the list: %v", missing)
+		}
+		var unknown *UnknownOptionError
+		if !errors.As(err, &unknown) || unknown.Key != "--region" {
+			t.Fatalf("errors.As must find the first matching error: %v", unknown)
+		}
+	})
+
+	t.Run("Errors after parsing are collected", func(t *testing.T) {
+		parser := Parser{CollectErrors: true}
+		result := struct {
+			JSON  bool     `arg:"--json" xor:"output"`
+			Table bool     `arg:"--table" xor:"output"`
+			Tags  []string `arg:"--tag" mincount:"2"`
+			Name  string   `arg:"--name,required"`
+		}{}
+		err := parser.ParseString("--json --table --tag a", &result)
+
+		var list ErrorList
+		if !errors.As(err, &list) || len(list) != 3 {
+			t.Fatalf("expected 3 errors, got %v", err)
+		}
+	})
+
+	t.Run("Attached value goes away with the invalid key", func(t *testing.T) {
+		result := struct {
+			Limit   int  `arg:"--limit"`
+			Color   bool `arg:"--color,negatable"`
+			Verbose bool `arg:"-v"`
+		}{}
+
+		cases := map[string]string{
+			"--lmit=5":                "unknown long key: --lmit, did you mean --limit?",
+			`-x"5"`:                   "unknown short key: -x",
+			`-xv"5"`:                  "unknown short key: -x",
+			"--no-color --color=true": "conflicting options: --no-color and --color",
+			"--color --no-color=x":    "invalid value for --color: --no-color does not accept a value",
+		}
+		parser := Parser{CollectErrors: true}
+		for input, expected := range cases {
+			err := parser.ParseString(input, &result)
+			var list ErrorList
+			if !errors.As(err, &list) || len(list) != 1 || list[0].Error() != expected {
+				t.Fatalf("expected only %q for %q, got %v", expected, input, err)
+			}
+		}
+	})
+
+	t.Run("Help is returned immediately", func(t *testing.T) {
+		parser := Parser{CollectErrors: true}
+		err := parser.ParseString("--limit abc --help", &args{})
+		if err != ErrHelp {
+			t.Fatalf("expected ErrHelp, got %v", err)
+		}
+	})
+
+	t.Run("Without errors the result is nil", func(t *testing.T) {
+		parser := Parser{CollectErrors: true}
+		result := args{}
+		if err := parser.ParseString("--limit 1 --user u", &result); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Only the first error without the option", func(t *testing.T) {
+		parser := Parser{}
+		err := parser.ParseString("--limit abc --region eu", &args{})
+		var list ErrorList
+		if errors.As(err, &list) {
+			t.Fatalf("unexpected ErrorList: %v", err)
+		}
+		var invalid *InvalidValueError
+		if !errors.As(err, &invalid) {
+			t.Fatalf("expected InvalidValueError, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,56 @@
+package argoparser
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// ErrorFormatter renders parse errors with the input and an underline under
+// the offending token:
+//
+//	invalid value for --limit: "abc" is not a valid int
+//	  --limit abc --env production
+//	          ^~~
+//
+// Input is the string passed to ParseString. For ParseSlice and ParseAppArgs
+// it's the arguments joined by spaces.
+type ErrorFormatter struct {
+	Input string
+}
+
+// Format renders the error. Errors without location in the input are rendered
+// as is, errors of ErrorList are rendered one after another.
+func (f ErrorFormatter) Format(err error) string {
+	var list ErrorList
+	if errors.As(err, &list) {
+		rendered := make([]string, 0, len(list))
+		for _, item := range list {
+			rendered = append(rendered, f.Format(item))
+		}
+		return strings.Join(rendered, "\n")
+	}
+
+	var located interface{ location() Location }
+	if !errors.As(err, &located) {
+		return err.Error()
+	}
+	location := located.location()
+	input := []rune(f.Input)
+	if location.Offset < 0 || location.Offset > len(input) {
+		return err.Error()
+	}
+
+	// line breaks and tabs would break the alignment of the underline
+	line := strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return ' '
+		}
+		return r
+	}, f.Input)
+	underline := "^" + strings.Repeat("~", max(0, location.Length-1))
+
+	return err.Error() + "\n" +
+		"  " + line + "\n" +
+		"  " + strings.Repeat(" ", location.Offset) + underline
+}
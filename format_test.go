@@ -0,0 +1,123 @@
+package argoparser
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestErrorFormatter(t *testing.T) {
+	type args struct {
+		Limit int    `arg:"--limit,-l"`
+		Env   string `arg:"--env" choices:"testing|production"`
+		User  string `arg:"--user,required"`
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		collect bool
+		want    string
+	}{
+		{
+			name:  "Invalid value",
+			input: "--limit abc --env production",
+			want: `invalid value for --limit: "abc" is not a valid int
+  --limit abc --env production
+          ^~~`,
+		},
+		{
+			name:  "Attached value",
+			input: `--user u --env="prod"`,
+			want: `invalid value for --env: "prod" is not one of testing|production
+  --user u --env="prod"
+                 ^~~~~~`,
+		},
+		{
+			name:  "Value in short group",
+			input: "--user u -l1O",
+			want: `invalid value for --limit: "1O" is not a valid int
+  --user u -l1O
+           ^~~~`,
+		},
+		{
+			name:  "Unknown key after multibyte text",
+			input: `--user "Саша" --lmit 5`,
+			want: `unknown long key: --lmit, did you mean --limit?
+  --user "Саша" --lmit 5
+                ^~~~~~`,
+		},
+		{
+			name:  "Missing value",
+			input: "--user u --env",
+			want: `missing value for flag: --env
+  --user u --env
+           ^~~~~`,
+		},
+		{
+			name:  "Unclosed quote",
+			input: `--user "u --limit 1`,
+			want: `syntax error: unclosed quote "
+  --user "u --limit 1
+         ^~~~~~~~~~~~`,
+		},
+		{
+			name:  "Error without location",
+			input: "--limit 1",
+			want:  "missing required option: --user",
+		},
+		{
+			name:    "Error list",
+			input:   "--limit abc\t--usr u",
+			collect: true,
+			want: `invalid value for --limit: "abc" is not a valid int
+  --limit abc --usr u
+          ^~~
+unknown long key: --usr, did you mean --user?
+  --limit abc --usr u
+              ^~~~~
+unexpected positional parameter: u
+  --limit abc --usr u
+                    ^
+missing required option: --user`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			parser := Parser{CollectErrors: test.collect}
+			err := parser.ParseString(test.input, &args{})
+			if err == nil {
+				t.Fatalf("expected error")
+			}
+			got := ErrorFormatter{Input: test.input}.Format(err)
+			if got != test.want {
+				t.Fatalf("expected:\n%s\ngot:\n%s", test.want, got)
+			}
+		})
+	}
+}
+
+func TestErrorFormatterAppArgs(t *testing.T) {
+	args := os.Args
+	defer func() { os.Args = args }()
+	os.Args = []string{"app", "a b", "--limit=ten"}
+
+	result := struct {
+		Name  string `arg:"positional"`
+		Limit int    `arg:"--limit"`
+	}{}
+	parser := Parser{}
+	err := parser.ParseAppArgs(&result)
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+
+	got := ErrorFormatter{Input: strings.Join(os.Args[1:], " ")}.Format(err)
+	want := `invalid value for --limit: "ten" is not a valid int
+  a b --limit=ten
+              ^~~`
+	if got != want {
+		t.Fatalf("expected:\n%s\ngot:\n%s", want, got)
+	}
+}
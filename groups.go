@@ -0,0 +1,147 @@
+package argoparser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// optionGroup is a set of fields with the same xor or and tag. At most one
+// field of xor group may be passed, fields of and group are passed all or none.
+type optionGroup struct {
+	name    string
+	isXor   bool
+	entries []*indexEntry
+}
+
+// relation is a dependency between two fields from requires or conflicts tag
+type relation struct {
+	entry      *indexEntry
+	other      *indexEntry
+	isConflict bool
+}
+
+// splitKeys splits comma-separated keys of requires and conflicts tags
+func splitKeys(tag string) []string {
+	keys := make([]string, 0)
+	for _, key := range strings.Split(tag, ",") {
+		key = strings.TrimSpace(key)
+		if key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func (index *fieldsIndex) addToGroup(name string, isXor bool, entry *indexEntry) {
+	for _, group := range index.groups {
+		if group.name == name && group.isXor == isXor {
+			group.entries = append(group.entries, entry)
+			return
+		}
+	}
+	index.groups = append(index.groups, &optionGroup{
+		name:    name,
+		isXor:   isXor,
+		entries: []*indexEntry{entry},
+	})
+}
+
+// lookupKey searches for the long or short key in the index and its parents
+func (index *fieldsIndex) lookupKey(key string) (*indexEntry, bool) {
+	if strings.HasPrefix(key, "--") {
+		return index.lookupLongName(key)
+	}
+	return index.lookupShortName(key)
+}
+
+// resolveRelations finds fields referenced by requires and conflicts tags.
+// Keys of parent commands may be referenced too.
+func (index *fieldsIndex) resolveRelations() error {
+	for _, entry := range index.entries {
+		for _, tag := range []struct {
+			name       string
+			keys       []string
+			isConflict bool
+		}{
+			{name: "requires", keys: entry.m.requires},
+			{name: "conflicts", keys: entry.m.conflicts, isConflict: true},
+		} {
+			for _, key := range tag.keys {
+				other, ok := index.lookupKey(key)
+				if !ok {
+					return &DefinitionError{Field: entry.path, Err: fmt.Errorf("unknown key in %s tag: %s", tag.name, key)}
+				}
+				if other == entry {
+					return &DefinitionError{Field: entry.path, Err: fmt.Errorf("field %s itself", tag.name)}
+				}
+				index.relations = append(index.relations, relation{
+					entry:      entry,
+					other:      other,
+					isConflict: tag.isConflict,
+				})
+			}
+		}
+	}
+	return nil
+}
+
+// checkGroups validates xor and and groups and requires and conflicts tags.
+// Fields set from the input or environment are considered passed, defaults are not.
+func checkGroups(index *fieldsIndex, errs *errorCollector) {
+	for _, group := range index.groups {
+		passed := make([]*indexEntry, 0)
+		missing := make([]*indexEntry, 0)
+		for _, entry := range group.entries {
+			if entry.presented {
+				passed = append(passed, entry)
+			} else {
+				missing = append(missing, entry)
+			}
+		}
+
+		if group.isXor && len(passed) > 1 {
+			err := &ConflictError{
+				Options: []string{passed[0].m.displayName(), passed[1].m.displayName()},
+				Group:   group.name,
+			}
+			if !errs.add(err) {
+				return
+			}
+		}
+		if !group.isXor && len(passed) > 0 && len(missing) > 0 {
+			err := &MissingRequiredError{
+				Field:      missing[0].path,
+				Option:     missing[0].m.displayName(),
+				RequiredBy: passed[0].m.displayName(),
+				Group:      group.name,
+			}
+			if !errs.add(err) {
+				return
+			}
+		}
+	}
+
+	for _, relation := range index.relations {
+		if !relation.entry.presented {
+			continue
+		}
+		if relation.isConflict && relation.other.presented {
+			err := &ConflictError{
+				Options: []string{relation.entry.m.displayName(), relation.other.m.displayName()},
+			}
+			if !errs.add(err) {
+				return
+			}
+		}
+		if !relation.isConflict && !relation.other.presented {
+			err := &MissingRequiredError{
+				Field:      relation.other.path,
+				Option:     relation.other.m.displayName(),
+				RequiredBy: relation.entry.m.displayName(),
+			}
+			if !errs.add(err) {
+				return
+			}
+		}
+	}
+}
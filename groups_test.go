@@ -0,0 +1,144 @@
+package argoparser
+
+import (
+	"os"
+	"testing"
+)
+
+type testGroupsArgs struct {
+	JSON   bool   `arg:"--json,-j" xor:"output"`
+	Table  bool   `arg:"--table" xor:"output"`
+	Cert   string `arg:"--cert" and:"tls"`
+	Key    string `arg:"--key" and:"tls"`
+	DryRun bool   `arg:"--dry-run"`
+	Force  bool   `arg:"--force,-f" conflicts:"--dry-run"`
+	Token  string `arg:"--token" requires:"--user,-j"`
+	User   string `arg:"--user"`
+}
+
+func TestGroups(t *testing.T) {
+	tc := []TestCase{
+		{
+			Name:   "One option of xor group",
+			Input:  "--json",
+			Result: testGroupsArgs{JSON: true},
+		},
+		{
+			Name:              "Two options of xor group",
+			Input:             "--table -j",
+			Result:            testGroupsArgs{},
+			ShouldReturnError: true,
+		},
+		{
+			Name:   "All options of and group",
+			Input:  "--cert a.pem --key a.key",
+			Result: testGroupsArgs{Cert: "a.pem", Key: "a.key"},
+		},
+		{
+			Name:              "Partial and group",
+			Input:             "--key a.key",
+			Result:            testGroupsArgs{},
+			ShouldReturnError: true,
+		},
+		{
+			Name:              "Conflicting options",
+			Input:             "-f --dry-run",
+			Result:            testGroupsArgs{},
+			ShouldReturnError: true,
+		},
+		{
+			Name:   "Conflicts tag is one-way",
+			Input:  "--force",
+			Result: testGroupsArgs{Force: true},
+		},
+		{
+			Name:   "Required options are passed",
+			Input:  "--token t --user u -j",
+			Result: testGroupsArgs{Token: "t", User: "u", JSON: true},
+		},
+		{
+			Name:              "Required option is missing",
+			Input:             "--token t --user u",
+			Result:            testGroupsArgs{},
+			ShouldReturnError: true,
+		},
+		{
+			Name:  "Default value doesn't count as passed",
+			Input: "--json",
+			Result: struct {
+				JSON  bool   `arg:"--json" xor:"output"`
+				Table bool   `arg:"--table" xor:"output" default:"false"`
+				Cert  string `arg:"--cert" and:"tls" default:"a.pem"`
+				Key   string `arg:"--key" and:"tls"`
+			}{JSON: true, Cert: "a.pem"},
+		},
+		{
+			Name:  "Unknown key in requires tag",
+			Input: "",
+			Result: struct {
+				Token string `arg:"--token" requires:"--user"`
+			}{},
+			ShouldReturnError: true,
+		},
+		{
+			Name:  "Option requires itself",
+			Input: "",
+			Result: struct {
+				Token string `arg:"--token,-t" requires:"-t"`
+			}{},
+			ShouldReturnError: true,
+		},
+	}
+
+	for _, testCase := range tc {
+		impl(t, testCase, false)
+	}
+
+	t.Run("Error messages", func(t *testing.T) {
+		parser := Parser{}
+		cases := map[string]string{
+			"--json --table":    "conflicting options in group output: --json and --table",
+			"--cert a.pem":      "options of group tls must be passed together: --cert is passed without --key",
+			"--force --dry-run": "conflicting options: --force and --dry-run",
+			"--token t -j":      "option --token requires --user",
+		}
+		for input, expected := range cases {
+			result := testGroupsArgs{}
+			err := parser.ParseString(input, &result)
+			if err == nil || err.Error() != expected {
+				t.Fatalf("unexpected error for %q: %v", input, err)
+			}
+		}
+	})
+
+	t.Run("Environment variables count as passed", func(t *testing.T) {
+		os.Setenv("ARGO_TEST_TABLE", "true")
+		defer os.Unsetenv("ARGO_TEST_TABLE")
+
+		result := struct {
+			JSON  bool `arg:"--json" xor:"output"`
+			Table bool `arg:"--table" xor:"output" env:"ARGO_TEST_TABLE"`
+		}{}
+		parser := Parser{}
+		if err := parser.ParseString("--json", &result); err == nil {
+			t.Fatalf("expected error")
+		}
+	})
+
+	t.Run("Requires key of parent command", func(t *testing.T) {
+		type getCmd struct {
+			ID string `arg:"--id" requires:"--token"`
+		}
+		result := struct {
+			Token string  `arg:"--token"`
+			Get   *getCmd `cmd:"get"`
+		}{}
+		parser := Parser{}
+		if err := parser.ParseString("get --id 1 --token t", &result); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if err := parser.ParseString("get --id 1", &result); err == nil {
+			t.Fatalf("expected error")
+		}
+	})
+}
@@ -0,0 +1,321 @@
+package argoparser
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// ErrHelp is returned by Parse methods if -h or --help is passed
+// and the struct doesn't define these keys itself.
+var ErrHelp = errors.New("help requested")
+
+const (
+	helpLongName  = "--help"
+	helpShortName = "-h"
+)
+
+// helpTarget is the struct help is rendered for. Commands selected during
+// parsing are allocated, so help is rendered for the deepest of them.
+type helpTarget struct {
+	index *fieldsIndex
+	path  []string
+}
+
+func (p *Parser) programName() string {
+	if p.Name != "" {
+		return p.Name
+	}
+	return filepath.Base(os.Args[0])
+}
+
+func (p *Parser) findHelpTarget(v any) (helpTarget, error) {
+	index, err := p.buildIndex(v)
+	if err != nil {
+		return helpTarget{}, err
+	}
+
+	target := helpTarget{index: index}
+
+	for {
+		var selected *indexEntry
+		for _, command := range target.index.commandEntries {
+			if !command.v.IsNil() {
+				selected = command
+				break
+			}
+		}
+		if selected == nil {
+			return target, nil
+		}
+
+		commandIndex, err := p.buildStructIndex(selected.v.Elem(), target.index, selected.path)
+		if err != nil {
+			return helpTarget{}, err
+		}
+		target.index = commandIndex
+		target.path = append(target.path, selected.m.commandName)
+	}
+}
+
+// Usage returns the usage line for the struct, like
+// "Usage: app [options] <module> [arguments...]".
+// If some command was selected during parsing, usage of the command is returned.
+// Empty string is returned for invalid struct.
+func (p *Parser) Usage(v any) string {
+	target, err := p.findHelpTarget(v)
+	if err != nil {
+		return ""
+	}
+
+	return p.usageLine(target)
+}
+
+func (p *Parser) usageLine(target helpTarget) string {
+	parts := append([]string{"Usage:", p.programName()}, target.path...)
+	parts = append(parts, "[options]")
+
+	for i := 0; i < len(target.index.fieldsByIndex); i++ {
+		entry := target.index.fieldsByIndex[i]
+		if entry.m.isRequired {
+			parts = append(parts, "<"+positionalName(entry)+">")
+		} else {
+			parts = append(parts, "["+positionalName(entry)+"]")
+		}
+	}
+	if target.index.positionalsDefault != nil {
+		parts = append(parts, "["+positionalName(target.index.positionalsDefault)+"...]")
+	}
+	if len(target.index.commandEntries) > 0 {
+		parts = append(parts, "<command>")
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// WriteHelp writes the full help for the struct: usage line, positional
+// arguments, options and commands. Like Usage, it takes commands selected
+// during parsing into account.
+func (p *Parser) WriteHelp(w io.Writer, v any) error {
+	target, err := p.findHelpTarget(v)
+	if err != nil {
+		return err
+	}
+
+	buffer := &strings.Builder{}
+	tw := tabwriter.NewWriter(buffer, 0, 0, 3, ' ', 0)
+
+	fmt.Fprintln(tw, p.usageLine(target))
+
+	positionals := make([]*indexEntry, 0)
+	for i := 0; i < len(target.index.fieldsByIndex); i++ {
+		positionals = append(positionals, target.index.fieldsByIndex[i])
+	}
+	if target.index.positionalsDefault != nil {
+		positionals = append(positionals, target.index.positionalsDefault)
+	}
+	if len(positionals) > 0 {
+		fmt.Fprintln(tw)
+		fmt.Fprintln(tw, "Positional arguments:")
+		for _, entry := range positionals {
+			writeRow(tw, positionalName(entry), typeName(entry), p.entryDescription(entry))
+		}
+	}
+
+	// options of xor and and groups are listed in their own sections,
+	// an option of both groups is listed in the first one
+	groups := make([]*optionGroup, 0)
+	grouped := make(map[*indexEntry]*optionGroup)
+	for current := target.index; current != nil; current = current.parent {
+		for _, group := range current.groups {
+			groups = append(groups, group)
+			for _, entry := range group.entries {
+				if _, ok := grouped[entry]; !ok && !entry.m.isPositional {
+					grouped[entry] = group
+				}
+			}
+		}
+	}
+
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "Options:")
+	for current := target.index; current != nil; current = current.parent {
+		for _, entry := range current.entries {
+			if _, ok := grouped[entry]; ok || entry.m.isPositional {
+				continue
+			}
+			writeRow(tw, optionNames(entry), typeName(entry), p.entryDescription(entry))
+		}
+	}
+	_, hasLongHelp := target.index.lookupLongName(helpLongName)
+	_, hasShortHelp := target.index.lookupShortName(helpShortName)
+	if !hasLongHelp {
+		names := "    " + helpLongName
+		if !hasShortHelp {
+			names = helpShortName + ", " + helpLongName
+		}
+		writeRow(tw, names, "", "show this help")
+	}
+
+	for _, group := range groups {
+		entries := make([]*indexEntry, 0)
+		for _, entry := range group.entries {
+			if grouped[entry] == group {
+				entries = append(entries, entry)
+			}
+		}
+		if len(entries) == 0 {
+			continue
+		}
+
+		fmt.Fprintln(tw)
+		if group.isXor {
+			fmt.Fprintf(tw, "Mutually exclusive options (%s):\n", group.name)
+		} else {
+			fmt.Fprintf(tw, "Options used together (%s):\n", group.name)
+		}
+		for _, entry := range entries {
+			writeRow(tw, optionNames(entry), typeName(entry), p.entryDescription(entry))
+		}
+	}
+
+	if len(target.index.commandEntries) > 0 {
+		fmt.Fprintln(tw)
+		fmt.Fprintln(tw, "Commands:")
+		for _, command := range target.index.commandEntries {
+			writeRow(tw, command.m.commandName, command.m.help)
+		}
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	// empty cells in the last column leave trailing spaces after alignment
+	lines := strings.Split(buffer.String(), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], " ")
+	}
+	_, err = io.WriteString(w, strings.Join(lines, "\n"))
+	return err
+}
+
+func writeRow(w io.Writer, cells ...string) {
+	fmt.Fprintln(w, "  "+strings.Join(cells, "\t"))
+}
+
+func positionalName(entry *indexEntry) string {
+	return deriveLongName(entry.m.name)
+}
+
+func optionNames(entry *indexEntry) string {
+	longName := entry.m.longName
+	if entry.m.isNegatable {
+		longName = "--[no-]" + strings.TrimPrefix(longName, "--")
+	}
+	if entry.m.shortName == "" {
+		return "    " + longName
+	}
+	return entry.m.shortName + ", " + longName
+}
+
+func typeName(entry *indexEntry) string {
+	if isFlag(entry) && !entry.m.isPositional {
+		return ""
+	}
+	if entry.t.Kind() == reflect.Ptr {
+		return entry.t.Elem().String()
+	}
+	return entry.t.String()
+}
+
+// entryDescription joins help text of the field with its markers: required,
+// environment variable and default value
+func (p *Parser) entryDescription(entry *indexEntry) string {
+	parts := make([]string, 0)
+	if entry.m.help != "" {
+		parts = append(parts, entry.m.help)
+	}
+	if entry.m.isRequired {
+		parts = append(parts, "(required)")
+	}
+	if entry.m.isCounter {
+		parts = append(parts, "(repeatable)")
+	}
+	if len(entry.m.requires) > 0 {
+		parts = append(parts, "(requires: "+strings.Join(entry.m.requires, ", ")+")")
+	}
+	if len(entry.m.conflicts) > 0 {
+		parts = append(parts, "(conflicts: "+strings.Join(entry.m.conflicts, ", ")+")")
+	}
+	if len(entry.m.choices) > 0 {
+		parts = append(parts, "(choices: "+strings.Join(entry.m.choices, "|")+")")
+	}
+	if name, ok := p.envName(entry); ok {
+		parts = append(parts, "(env: "+name+")")
+	}
+	if defaultValue, ok := formatDefault(entry); ok {
+		parts = append(parts, "(default: "+defaultValue+")")
+	}
+	return strings.Join(parts, " ")
+}
+
+// formatDefault renders value from default tag or pre-filled in the struct, if any
+func formatDefault(entry *indexEntry) (string, bool) {
+	if entry.m.hasDefault {
+		return entry.m.defaultValue, entry.m.defaultValue != ""
+	}
+	if entry.v.IsZero() || isFlag(entry) {
+		return "", false
+	}
+	if entry.v.Kind() == reflect.Slice {
+		if entry.v.Len() == 0 {
+			return "", false
+		}
+		items := make([]string, 0, entry.v.Len())
+		for i := 0; i < entry.v.Len(); i++ {
+			items = append(items, formatValue(entry.v.Index(i)))
+		}
+		return strings.Join(items, ","), true
+	}
+	if entry.v.Kind() == reflect.Map {
+		if entry.v.Len() == 0 {
+			return "", false
+		}
+		separator := entry.m.separator
+		if separator == "" {
+			separator = "="
+		}
+		items := make([]string, 0, entry.v.Len())
+		iter := entry.v.MapRange()
+		for iter.Next() {
+			items = append(items, formatValue(iter.Key())+separator+formatValue(iter.Value()))
+		}
+		sort.Strings(items)
+		return strings.Join(items, ","), true
+	}
+	return formatValue(entry.v), true
+}
+
+// formatValue renders the value, taking into account String methods with
+// pointer receivers and pointer fields
+func formatValue(v reflect.Value) string {
+	if v.CanAddr() {
+		if stringer, ok := v.Addr().Interface().(fmt.Stringer); ok {
+			return stringer.String()
+		}
+	}
+	if v.Kind() == reflect.Ptr && !v.IsNil() {
+		if stringer, ok := v.Interface().(fmt.Stringer); ok {
+			return stringer.String()
+		}
+		return formatValue(v.Elem())
+	}
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,154 @@
+package argoparser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type testHelpGetCmd struct {
+	UserID int    `arg:"--user-id,-u,required" help:"id of user to get subscriptions for"`
+	Status string `arg:"positional" help:"subscription status"`
+}
+
+type testHelpArgs struct {
+	Limit    int             `arg:"-l" help:"limit the number of subscriptions to return"`
+	Env      string          `arg:"--env"`
+	Format   string          `arg:"--format" default:"table" choices:"json|table"`
+	JSON     bool            `arg:"--json,-j" help:"machine-readable output"`
+	Verbose  int             `arg:"-v,count" help:"verbosity level"`
+	Color    bool            `arg:"--color,negatable" help:"colorize output"`
+	Tags     []string        `arg:"--tag"`
+	Module   string          `arg:"positional,required" help:"module name"`
+	Products []string        `arg:"positional" help:"list of products"`
+	Get      *testHelpGetCmd `cmd:"get" help:"get subscriptions"`
+}
+
+func TestWriteHelp(t *testing.T) {
+	parser := Parser{Name: "subctl"}
+	args := testHelpArgs{
+		Env:  "testing",
+		Tags: []string{"a", "b"},
+	}
+
+	builder := &strings.Builder{}
+	if err := parser.WriteHelp(builder, &args); err != nil {
+		t.Fatalf("WriteHelp failed: %s", err)
+	}
+
+	expected := `Usage: subctl [options] <module> [products...] <command>
+
+Positional arguments:
+  module     string     module name (required)
+  products   []string   list of products
+
+Options:
+  -l, --limit        int        limit the number of subscriptions to return
+      --env          string     (default: testing)
+      --format       string     (choices: json|table) (default: table)
+  -j, --json                    machine-readable output
+  -v, --verbose                 verbosity level (repeatable)
+      --[no-]color              colorize output
+      --tag          []string   (default: a,b)
+  -h, --help                    show this help
+
+Commands:
+  get   get subscriptions
+`
+	if builder.String() != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, builder.String())
+	}
+
+	if len(args.Tags) != 2 {
+		t.Fatalf("WriteHelp must not modify the struct, got %v", args.Tags)
+	}
+}
+
+func TestHelpForCommand(t *testing.T) {
+	parser := Parser{Name: "subctl"}
+	args := testHelpArgs{}
+
+	err := parser.ParseString("core get --help", &args)
+	if !errors.Is(err, ErrHelp) {
+		t.Fatalf("expected ErrHelp, got %v", err)
+	}
+
+	usage := parser.Usage(&args)
+	if usage != "Usage: subctl get [options] [status]" {
+		t.Fatalf("unexpected usage: %s", usage)
+	}
+
+	builder := &strings.Builder{}
+	if err := parser.WriteHelp(builder, &args); err != nil {
+		t.Fatalf("WriteHelp failed: %s", err)
+	}
+	// compare without alignment, it depends on other options
+	help := strings.Join(strings.Fields(builder.String()), " ")
+	if !strings.Contains(help, "-u, --user-id int id of user to get subscriptions for (required)") {
+		t.Fatalf("command options are not listed:\n%s", builder.String())
+	}
+	if !strings.Contains(help, "-l, --limit") {
+		t.Fatalf("parent options are not listed:\n%s", builder.String())
+	}
+}
+
+func TestHelpGroups(t *testing.T) {
+	parser := Parser{Name: "deploy"}
+	args := struct {
+		DryRun bool   `arg:"--dry-run"`
+		Force  bool   `arg:"--force" conflicts:"--dry-run"`
+		JSON   bool   `arg:"--json" xor:"output"`
+		Table  bool   `arg:"--table" xor:"output"`
+		Cert   string `arg:"--cert" and:"tls" requires:"--json"`
+		Key    string `arg:"--key" and:"tls"`
+	}{}
+
+	builder := &strings.Builder{}
+	if err := parser.WriteHelp(builder, &args); err != nil {
+		t.Fatalf("WriteHelp failed: %s", err)
+	}
+
+	expected := `Usage: deploy [options]
+
+Options:
+      --dry-run
+      --force        (conflicts: --dry-run)
+  -h, --help         show this help
+
+Mutually exclusive options (output):
+      --json
+      --table
+
+Options used together (tls):
+      --cert   string   (requires: --json)
+      --key    string
+`
+	if builder.String() != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, builder.String())
+	}
+}
+
+func TestErrHelp(t *testing.T) {
+	t.Run("Short and long keys return ErrHelp", func(t *testing.T) {
+		for _, input := range []string{"-h", "--help", "-l 5 --help", "-vh"} {
+			args := testHelpArgs{}
+			parser := Parser{}
+			if err := parser.ParseString(input, &args); !errors.Is(err, ErrHelp) {
+				t.Fatalf("expected ErrHelp for %q, got %v", input, err)
+			}
+		}
+	})
+
+	t.Run("Keys defined by the struct are not help", func(t *testing.T) {
+		args := struct {
+			Host string `arg:"-h"`
+		}{}
+		parser := Parser{}
+		if err := parser.ParseString("-h localhost", &args); err != nil {
+			t.Fatalf("ParseString failed: %s", err)
+		}
+		if args.Host != "localhost" {
+			t.Fatalf("expected localhost, got %s", args.Host)
+		}
+	})
+}
@@ -0,0 +1,83 @@
+package argoparser
+
+import "reflect"
+
+// BeforeParser is implemented by structs which prepare themselves before
+// parsing. Values set in BeforeParse are kept as defaults, like pre-filled ones.
+// For commands the hook is called once the command is met in the input.
+type BeforeParser interface {
+	BeforeParse() error
+}
+
+// AfterParser is implemented by structs which compute derived values after
+// parsing, when defaults and environment variables are already applied
+type AfterParser interface {
+	AfterParse() error
+}
+
+// Validator is implemented by structs with rules which cannot be expressed
+// in tags, like dependencies between values of fields. Validate is called
+// after AfterParse.
+type Validator interface {
+	Validate() error
+}
+
+var (
+	beforeParserType = reflect.TypeOf((*BeforeParser)(nil)).Elem()
+	afterParserType  = reflect.TypeOf((*AfterParser)(nil)).Elem()
+	validatorType    = reflect.TypeOf((*Validator)(nil)).Elem()
+)
+
+// callBeforeParse calls BeforeParse of the struct of the index and of the
+// structs embedded into it
+func (p *Parser) callBeforeParse(index *fieldsIndex) error {
+	for _, rv := range p.hookTargets(index.value, beforeParserType) {
+		if err := rv.Addr().Interface().(BeforeParser).BeforeParse(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// callAfterParse calls AfterParse and then Validate hooks of passed commands
+// and the top level struct. Nested commands go first, so the parent sees the
+// final values of its commands.
+func (p *Parser) callAfterParse(indexes []*fieldsIndex) error {
+	for i := len(indexes) - 1; i >= 0; i-- {
+		for _, rv := range p.hookTargets(indexes[i].value, afterParserType) {
+			if err := rv.Addr().Interface().(AfterParser).AfterParse(); err != nil {
+				return err
+			}
+		}
+	}
+
+	for i := len(indexes) - 1; i >= 0; i-- {
+		for _, rv := range p.hookTargets(indexes[i].value, validatorType) {
+			if err := rv.Addr().Interface().(Validator).Validate(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// hookTargets returns structs which the hook is called for. Hooks follow Go
+// method sets: if the struct has the hook, declared or promoted from embedded
+// struct, only the struct is returned, so the declared hook overrides embedded
+// ones and the promoted one is called once. Otherwise embedded structs are
+// searched, that's the case of embedded structs with conflicting hooks.
+func (p *Parser) hookTargets(rv reflect.Value, hook reflect.Type) []reflect.Value {
+	if rv.Addr().Type().Implements(hook) {
+		return []reflect.Value{rv}
+	}
+
+	targets := make([]reflect.Value, 0)
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		if embedded, err := p.isEmbeddedStruct(rt.Field(i)); err == nil && embedded {
+			targets = append(targets, p.hookTargets(rv.Field(i), hook)...)
+		}
+	}
+	return targets
+}
@@ -0,0 +1,241 @@
+package argoparser
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testHooksGetCmd struct {
+	ID int `arg:"--id"`
+}
+
+func (c *testHooksGetCmd) BeforeParse() error {
+	c.ID = 1
+	return nil
+}
+
+func (c *testHooksGetCmd) Validate() error {
+	if c.ID < 0 {
+		return errors.New("id must not be negative")
+	}
+	return nil
+}
+
+type testHooksArgs struct {
+	Env     string           `arg:"--env" default:"testing"`
+	Limit   int              `arg:"--limit"`
+	Max     int              `arg:"--max"`
+	Workers int              `arg:"--workers"`
+	Name    string           `arg:"--name"`
+	Get     *testHooksGetCmd `cmd:"get"`
+}
+
+// testHooksCalls records calls of hooks, the struct cannot keep them
+// since unexported fields are not allowed
+var testHooksCalls []string
+
+func (a *testHooksArgs) BeforeParse() error {
+	testHooksCalls = append(testHooksCalls, "before")
+	a.Workers = 4
+	return nil
+}
+
+func (a *testHooksArgs) AfterParse() error {
+	testHooksCalls = append(testHooksCalls, "after")
+	if a.Name == "" {
+		a.Name = strings.ToUpper(a.Env)
+	}
+	return nil
+}
+
+func (a *testHooksArgs) Validate() error {
+	testHooksCalls = append(testHooksCalls, "validate")
+	if a.Env == "production" && a.Limit >= a.Max {
+		return fmt.Errorf("--limit must be less than --max in production")
+	}
+	return nil
+}
+
+type testHooksFailingArgs struct {
+	Limit int `arg:"--limit"`
+}
+
+func (a *testHooksFailingArgs) BeforeParse() error {
+	return errors.New("not ready")
+}
+
+type HooksTestCommon struct {
+	Timeout int `arg:"--timeout"`
+}
+
+func (c *HooksTestCommon) BeforeParse() error {
+	testHooksCalls = append(testHooksCalls, "common before")
+	c.Timeout = 30
+	return nil
+}
+
+func (c *HooksTestCommon) Validate() error {
+	testHooksCalls = append(testHooksCalls, "common validate")
+	if c.Timeout <= 0 {
+		return errors.New("timeout must be positive")
+	}
+	return nil
+}
+
+// testHooksOverridingArgs declares its own Validate, which overrides the one
+// of the embedded struct like in Go
+type testHooksOverridingArgs struct {
+	HooksTestCommon
+	Name string `arg:"--name"`
+}
+
+func (a *testHooksOverridingArgs) Validate() error {
+	testHooksCalls = append(testHooksCalls, "validate")
+	if a.Name == "" {
+		return errors.New("name is required")
+	}
+	return nil
+}
+
+// testHooksPromotingArgs gets hooks of the embedded struct by promotion
+type testHooksPromotingArgs struct {
+	HooksTestCommon
+}
+
+type HooksTestOutput struct {
+	Format string `arg:"--format"`
+}
+
+func (o *HooksTestOutput) Validate() error {
+	testHooksCalls = append(testHooksCalls, "output validate")
+	return nil
+}
+
+// testHooksConflictingArgs has no Validate, since embedded ones conflict
+type testHooksConflictingArgs struct {
+	HooksTestCommon
+	HooksTestOutput
+}
+
+func TestHooks(t *testing.T) {
+	t.Run("Hooks are called in order", func(t *testing.T) {
+		testHooksCalls = nil
+		result := testHooksArgs{}
+		parser := Parser{}
+		if err := parser.ParseString("--limit 5", &result); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if !reflect.DeepEqual(testHooksCalls, []string{"before", "after", "validate"}) {
+			t.Fatalf("unexpected calls: %v", testHooksCalls)
+		}
+		if result.Workers != 4 {
+			t.Fatalf("value from BeforeParse is not kept: %d", result.Workers)
+		}
+		if result.Name != "TESTING" {
+			t.Fatalf("AfterParse must see defaults, got %q", result.Name)
+		}
+	})
+
+	t.Run("Value from BeforeParse is overridden by input", func(t *testing.T) {
+		result := testHooksArgs{}
+		parser := Parser{}
+		if err := parser.ParseString("--workers 8", &result); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if result.Workers != 8 {
+			t.Fatalf("expected 8, got %d", result.Workers)
+		}
+	})
+
+	t.Run("Validate error is returned", func(t *testing.T) {
+		result := testHooksArgs{}
+		parser := Parser{}
+		err := parser.ParseString("--env production --limit 10 --max 5", &result)
+		if err == nil || err.Error() != "--limit must be less than --max in production" {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Hooks of commands", func(t *testing.T) {
+		result := testHooksArgs{}
+		parser := Parser{}
+		if err := parser.ParseString("get", &result); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if result.Get.ID != 1 {
+			t.Fatalf("BeforeParse of command is not called: %d", result.Get.ID)
+		}
+
+		result = testHooksArgs{}
+		err := parser.ParseString("get --id -5", &result)
+		if err == nil || err.Error() != "id must not be negative" {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("BeforeParse error is returned", func(t *testing.T) {
+		result := testHooksFailingArgs{}
+		parser := Parser{}
+		err := parser.ParseString("--limit 1", &result)
+		if err == nil || err.Error() != "not ready" {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	t.Run("Hook declared by the struct overrides embedded one", func(t *testing.T) {
+		testHooksCalls = nil
+		result := testHooksOverridingArgs{}
+		parser := Parser{}
+		if err := parser.ParseString("--name x --timeout 0", &result); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if !reflect.DeepEqual(testHooksCalls, []string{"common before", "validate"}) {
+			t.Fatalf("unexpected calls: %v", testHooksCalls)
+		}
+
+		err := parser.ParseString("", &testHooksOverridingArgs{})
+		if err == nil || err.Error() != "name is required" {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("BeforeParse of embedded struct", func(t *testing.T) {
+		result := testHooksOverridingArgs{}
+		parser := Parser{}
+		if err := parser.ParseString("--name x", &result); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if result.Timeout != 30 {
+			t.Fatalf("value from BeforeParse of embedded struct is not kept: %d", result.Timeout)
+		}
+	})
+
+	t.Run("Promoted hooks are called once", func(t *testing.T) {
+		testHooksCalls = nil
+		parser := Parser{}
+		if err := parser.ParseString("", &testHooksPromotingArgs{}); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if !reflect.DeepEqual(testHooksCalls, []string{"common before", "common validate"}) {
+			t.Fatalf("unexpected calls: %v", testHooksCalls)
+		}
+	})
+	t.Run("Conflicting hooks of embedded structs are called for each", func(t *testing.T) {
+		testHooksCalls = nil
+		parser := Parser{}
+		if err := parser.ParseString("", &testHooksConflictingArgs{}); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if !reflect.DeepEqual(testHooksCalls, []string{"common before", "common validate", "output validate"}) {
+			t.Fatalf("unexpected calls: %v", testHooksCalls)
+		}
+
+		err := parser.ParseString("--timeout 0", &testHooksConflictingArgs{})
+		if err == nil || err.Error() != "timeout must be positive" {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
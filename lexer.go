@@ -1,27 +1,36 @@
 package argoparser
 
 import (
+	"fmt"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
 type tokenType int
 
 const (
-	_               tokenType = iota
-	typeStringValue           // anything without hyphen: asdf, "asdf", 'asdf', `"asdf"` etc
-	typeShortGroup            // -s, -abc
-	typeLongKey               // --this-is-long-key
+	_                 tokenType = iota
+	typeStringValue             // anything without hyphen: asdf, "asdf", 'asdf', `"asdf"` etc
+	typeShortGroup              // -s, -abc
+	typeLongKey                 // --this-is-long-key
+	typeAttachedValue           // value glued to the key: --key=value, --key="value", -k"value"
+	typeTerminator              // lone --, everything after it is positional
 )
 
 type token struct {
 	TokenType tokenType
 	Value     string
+	// Offset and End are rune offsets of the token in the input, End is exclusive.
+	// Quotes are the part of the token, the = of attached value is not.
+	Offset int
+	End    int
 }
 
 type tokenBuilder struct {
 	TokenType tokenType
 	Value     *strings.Builder
+	Offset    int
 }
 
 type lexerState int
@@ -34,6 +43,7 @@ const (
 	stateReadingSimpleStrign
 	stateReadingQuotedString
 	stateEscaped
+	stateMetEquals
 )
 
 func isQuote(r rune) bool {
@@ -42,8 +52,10 @@ func isQuote(r rune) bool {
 
 /**
 * this is just a naive implementation of the automaton from lexerAutomaton.png
+* unclosed quote and backslash at the end of the input are errors, in lenient
+* mode the quoted string just ends with the input and the backslash is dropped
  */
-func lex(input string) []token {
+func lex(input string, lenient bool) ([]token, error) {
 	result := make([]token, 0)
 
 	runeSlice := []rune(input)
@@ -53,16 +65,35 @@ func lex(input string) []token {
 		Value: &strings.Builder{},
 	}
 	openedQuote := rune(0)
+	terminated := false
 
-	flushToken := func() {
+	// markTerminator turns lone -- into end-of-options terminator
+	markTerminator := func() {
+		if currentToken.TokenType == typeLongKey && currentToken.Value.String() == "--" {
+			currentToken.TokenType = typeTerminator
+			terminated = true
+		}
+	}
+
+	pos := 0
+
+	// flushTokenAt finishes the token, end is the offset after its last rune
+	flushTokenAt := func(end int) {
 		result = append(result, token{
 			TokenType: currentToken.TokenType,
 			Value:     currentToken.Value.String(),
+			Offset:    currentToken.Offset,
+			End:       end,
 		})
 		currentToken = tokenBuilder{
 			Value: &strings.Builder{},
 		}
 	}
+	// flushToken finishes the token at the current position, which is the
+	// delimiter after the token
+	flushToken := func() {
+		flushTokenAt(pos)
+	}
 
 	type moveToParams struct {
 		NewState     lexerState
@@ -84,10 +115,13 @@ func lex(input string) []token {
 		}
 	}
 
-	for pos := 0; pos < len(runeSlice); pos++ {
+	for ; pos < len(runeSlice); pos++ {
 		switch state {
 		case stateInitial:
-			if runeSlice[pos] == '-' {
+			if !unicode.IsSpace(runeSlice[pos]) {
+				currentToken.Offset = pos
+			}
+			if runeSlice[pos] == '-' && !terminated {
 				moveTo(moveToParams{
 					NewState:   stateMetHyphen,
 					AppendWith: runeSlice[pos],
@@ -115,9 +149,11 @@ func lex(input string) []token {
 					AppendWith:   runeSlice[pos],
 				})
 			} else if unicode.IsSpace(runeSlice[pos]) {
+				// lone - is a value
 				moveTo(moveToParams{
-					NewState:    stateInitial,
-					ShouldFlush: true,
+					NewState:     stateInitial,
+					NewTokenType: typeStringValue,
+					ShouldFlush:  true,
 				})
 			} else {
 				moveTo(moveToParams{
@@ -132,6 +168,14 @@ func lex(input string) []token {
 					NewState:    stateInitial,
 					ShouldFlush: true,
 				})
+			} else if isQuote(runeSlice[pos]) {
+				moveTo(moveToParams{
+					NewState:    stateReadingQuotedString,
+					ShouldFlush: true,
+				})
+				currentToken.TokenType = typeAttachedValue
+				currentToken.Offset = pos
+				openedQuote = runeSlice[pos]
 			} else {
 				moveTo(moveToParams{
 					NewState:   stateReadingShortGroup,
@@ -140,16 +184,42 @@ func lex(input string) []token {
 			}
 		case stateReadingLongKey:
 			if unicode.IsSpace(runeSlice[pos]) {
+				markTerminator()
 				moveTo(moveToParams{
 					NewState:    stateInitial,
 					ShouldFlush: true,
 				})
+			} else if runeSlice[pos] == '=' {
+				moveTo(moveToParams{
+					NewState:    stateMetEquals,
+					ShouldFlush: true,
+				})
+				currentToken.TokenType = typeAttachedValue
+				currentToken.Offset = pos + 1
 			} else {
 				moveTo(moveToParams{
 					NewState:   stateReadingLongKey,
 					AppendWith: runeSlice[pos],
 				})
 			}
+		case stateMetEquals:
+			if unicode.IsSpace(runeSlice[pos]) {
+				// --key= followed by space means empty value
+				moveTo(moveToParams{
+					NewState:    stateInitial,
+					ShouldFlush: true,
+				})
+			} else if isQuote(runeSlice[pos]) {
+				moveTo(moveToParams{
+					NewState: stateReadingQuotedString,
+				})
+				openedQuote = runeSlice[pos]
+			} else {
+				moveTo(moveToParams{
+					NewState:   stateReadingSimpleStrign,
+					AppendWith: runeSlice[pos],
+				})
+			}
 		case stateReadingSimpleStrign:
 			if unicode.IsSpace(runeSlice[pos]) {
 				moveTo(moveToParams{
@@ -165,9 +235,10 @@ func lex(input string) []token {
 		case stateReadingQuotedString:
 			if runeSlice[pos] == openedQuote {
 				moveTo(moveToParams{
-					NewState:    stateInitial,
-					ShouldFlush: true,
+					NewState: stateInitial,
 				})
+				// the closing quote is the part of the token
+				flushTokenAt(pos + 1)
 			} else if runeSlice[pos] == '\\' {
 				moveTo(moveToParams{
 					NewState: stateEscaped,
@@ -203,9 +274,66 @@ func lex(input string) []token {
 		}
 	}
 
-	if currentToken.TokenType != 0 && currentToken.Value.Len() > 0 {
+	if !lenient {
+		switch state {
+		case stateReadingQuotedString:
+			return nil, &SyntaxError{
+				Message:  fmt.Sprintf("unclosed quote %c", openedQuote),
+				Location: Location{Offset: currentToken.Offset, Length: pos - currentToken.Offset},
+			}
+		case stateEscaped:
+			return nil, &SyntaxError{
+				Message:  "backslash at the end of the input",
+				Location: Location{Offset: pos - 1, Length: 1},
+			}
+		}
+	}
+
+	if state == stateReadingLongKey {
+		markTerminator()
+	}
+	if state == stateMetHyphen {
+		currentToken.TokenType = typeStringValue
+	}
+
+	// opened quote means the value is passed even if it's empty: --key="
+	isQuoted := state == stateReadingQuotedString || state == stateEscaped
+	if state == stateMetEquals || isQuoted || currentToken.TokenType != 0 && currentToken.Value.Len() > 0 {
 		flushToken()
 	}
 
+	return result, nil
+}
+
+// lexArgs classifies arguments already split by the shell, like os.Args:
+// every argument is a single token, quotes and spaces are the part of values,
+// lone - is a value. Offsets are counted in the arguments joined by spaces.
+func lexArgs(args []string) []token {
+	result := make([]token, 0, len(args))
+
+	offset := 0
+	terminated := false
+	for _, arg := range args {
+		end := offset + utf8.RuneCountInString(arg)
+		if terminated {
+			result = append(result, token{TokenType: typeStringValue, Value: arg, Offset: offset, End: end})
+		} else if arg == "--" {
+			result = append(result, token{TokenType: typeTerminator, Value: arg, Offset: offset, End: end})
+			terminated = true
+		} else if strings.HasPrefix(arg, "--") {
+			key, value, hasValue := strings.Cut(arg, "=")
+			keyEnd := offset + utf8.RuneCountInString(key)
+			result = append(result, token{TokenType: typeLongKey, Value: key, Offset: offset, End: keyEnd})
+			if hasValue {
+				result = append(result, token{TokenType: typeAttachedValue, Value: value, Offset: keyEnd + 1, End: end})
+			}
+		} else if strings.HasPrefix(arg, "-") && arg != "-" {
+			result = append(result, token{TokenType: typeShortGroup, Value: arg, Offset: offset, End: end})
+		} else {
+			result = append(result, token{TokenType: typeStringValue, Value: arg, Offset: offset, End: end})
+		}
+		offset = end + 1
+	}
+
 	return result
 }
@@ -1,6 +1,7 @@
 package argoparser
 
 import (
+	"errors"
 	"reflect"
 	"testing"
 )
@@ -39,44 +40,248 @@ func TestLexer(t *testing.T) {
 			},
 		},
 		{
-			input: `-abc --key "strange value`,
+			input: `"a""b""c"`,
+			want: []token{
+				{TokenType: typeStringValue, Value: `a`},
+				{TokenType: typeStringValue, Value: `b`},
+				{TokenType: typeStringValue, Value: `c`},
+			},
+		},
+		{
+			input: `--k@y-" abc`,
+			want: []token{
+				{TokenType: typeLongKey, Value: `--k@y-"`},
+				{TokenType: typeStringValue, Value: `abc`},
+			},
+		},
+		{
+			input: `--key=value --quoted="spaced value" --empty= -k"v a l"`,
 			want: []token{
-				{TokenType: typeShortGroup, Value: "-abc"},
 				{TokenType: typeLongKey, Value: "--key"},
-				{TokenType: typeStringValue, Value: `strange value`},
+				{TokenType: typeAttachedValue, Value: "value"},
+				{TokenType: typeLongKey, Value: "--quoted"},
+				{TokenType: typeAttachedValue, Value: "spaced value"},
+				{TokenType: typeLongKey, Value: "--empty"},
+				{TokenType: typeAttachedValue, Value: ""},
+				{TokenType: typeShortGroup, Value: "-k"},
+				{TokenType: typeAttachedValue, Value: "v a l"},
 			},
 		},
-		// TODO: the case above with unclosed quote should most likely be an error
 		{
-			input: `-abc --key "strange value"" -def`,
+			input: `--key=a=b --empty=`,
 			want: []token{
-				{TokenType: typeShortGroup, Value: "-abc"},
 				{TokenType: typeLongKey, Value: "--key"},
-				{TokenType: typeStringValue, Value: `strange value`},
-				{TokenType: typeStringValue, Value: ` -def`},
+				{TokenType: typeAttachedValue, Value: "a=b"},
+				{TokenType: typeLongKey, Value: "--empty"},
+				{TokenType: typeAttachedValue, Value: ""},
 			},
 		},
 		{
-			input: `"a""b""c"`,
+			input: `-a -- -b --c "d e" --`,
 			want: []token{
-				{TokenType: typeStringValue, Value: `a`},
-				{TokenType: typeStringValue, Value: `b`},
-				{TokenType: typeStringValue, Value: `c`},
+				{TokenType: typeShortGroup, Value: "-a"},
+				{TokenType: typeTerminator, Value: "--"},
+				{TokenType: typeStringValue, Value: "-b"},
+				{TokenType: typeStringValue, Value: "--c"},
+				{TokenType: typeStringValue, Value: "d e"},
+				{TokenType: typeStringValue, Value: "--"},
 			},
 		},
 		{
-			input: `--k@y-" abc`,
+			input: `--`,
 			want: []token{
-				{TokenType: typeLongKey, Value: `--k@y-"`},
-				{TokenType: typeStringValue, Value: `abc`},
+				{TokenType: typeTerminator, Value: "--"},
+			},
+		},
+		{
+			input: `- a -`,
+			want: []token{
+				{TokenType: typeStringValue, Value: "-"},
+				{TokenType: typeStringValue, Value: "a"},
+				{TokenType: typeStringValue, Value: "-"},
 			},
 		},
 	}
 
 	for _, test := range tests {
-		got := lex(test.input)
+		got, err := lex(test.input, false)
+		if err != nil {
+			t.Errorf("lex(%q) returned error: %v", test.input, err)
+			continue
+		}
+		// offsets are checked in TestLexerOffsets
+		for i := range got {
+			got[i].Offset, got[i].End = 0, 0
+		}
 		if !reflect.DeepEqual(got, test.want) {
 			t.Errorf("lex(%q) = %v, want %v", test.input, got, test.want)
 		}
 	}
 }
+
+func TestLexerOffsets(t *testing.T) {
+	tests := []struct {
+		input string
+		want  [][2]int
+	}{
+		{input: "  asdf  -v", want: [][2]int{{2, 6}, {8, 10}}},
+		{input: `--key "spaced value" x`, want: [][2]int{{0, 5}, {6, 20}, {21, 22}}},
+		{input: `--key=value --empty= -k"v a l"`, want: [][2]int{{0, 5}, {6, 11}, {12, 19}, {20, 20}, {21, 23}, {23, 30}}},
+		{input: `привет -- -б`, want: [][2]int{{0, 6}, {7, 9}, {10, 12}}},
+		{input: `"a""b" 'c'`, want: [][2]int{{0, 3}, {3, 6}, {7, 10}}},
+	}
+
+	for _, test := range tests {
+		tokens, err := lex(test.input, false)
+		if err != nil {
+			t.Errorf("lex(%q) returned error: %v", test.input, err)
+			continue
+		}
+		got := make([][2]int, 0)
+		for _, token := range tokens {
+			got = append(got, [2]int{token.Offset, token.End})
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("offsets of lex(%q) = %v, want %v", test.input, got, test.want)
+		}
+	}
+}
+
+func TestLexerErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		want     string
+		location Location
+	}{
+		{input: `-abc --key "strange value`, want: `syntax error: unclosed quote "`, location: Location{Offset: 11, Length: 14}},
+		{input: `-abc --key "strange value"" -def`, want: `syntax error: unclosed quote "`, location: Location{Offset: 26, Length: 6}},
+		{input: `--key='value`, want: `syntax error: unclosed quote '`, location: Location{Offset: 6, Length: 6}},
+		{input: "-k`", want: "syntax error: unclosed quote `", location: Location{Offset: 2, Length: 1}},
+		{input: `--key "value\`, want: `syntax error: backslash at the end of the input`, location: Location{Offset: 12, Length: 1}},
+	}
+
+	for _, test := range tests {
+		_, err := lex(test.input, false)
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("lex(%q) returned %v, want SyntaxError", test.input, err)
+			continue
+		}
+		if err.Error() != test.want || syntaxErr.Location != test.location {
+			t.Errorf("lex(%q) = %q at %v, want %q at %v", test.input, err, syntaxErr.Location, test.want, test.location)
+		}
+	}
+}
+
+func TestLexerLenient(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []token
+	}{
+		{
+			input: `-abc --key "strange value`,
+			want: []token{
+				{TokenType: typeShortGroup, Value: "-abc", Offset: 0, End: 4},
+				{TokenType: typeLongKey, Value: "--key", Offset: 5, End: 10},
+				{TokenType: typeStringValue, Value: `strange value`, Offset: 11, End: 25},
+			},
+		},
+		{
+			input: `-abc --key "strange value"" -def`,
+			want: []token{
+				{TokenType: typeShortGroup, Value: "-abc", Offset: 0, End: 4},
+				{TokenType: typeLongKey, Value: "--key", Offset: 5, End: 10},
+				{TokenType: typeStringValue, Value: `strange value`, Offset: 11, End: 26},
+				{TokenType: typeStringValue, Value: ` -def`, Offset: 26, End: 32},
+			},
+		},
+		{
+			input: `--key "value\`,
+			want: []token{
+				{TokenType: typeLongKey, Value: "--key", Offset: 0, End: 5},
+				{TokenType: typeStringValue, Value: `value`, Offset: 6, End: 13},
+			},
+		},
+		{
+			input: `--key="`,
+			want: []token{
+				{TokenType: typeLongKey, Value: "--key", Offset: 0, End: 5},
+				{TokenType: typeAttachedValue, Value: "", Offset: 6, End: 7},
+			},
+		},
+		{
+			input: `-k"\`,
+			want: []token{
+				{TokenType: typeShortGroup, Value: "-k", Offset: 0, End: 2},
+				{TokenType: typeAttachedValue, Value: "", Offset: 2, End: 4},
+			},
+		},
+		{
+			input: `--key '`,
+			want: []token{
+				{TokenType: typeLongKey, Value: "--key", Offset: 0, End: 5},
+				{TokenType: typeStringValue, Value: "", Offset: 6, End: 7},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := lex(test.input, true)
+		if err != nil {
+			t.Errorf("lex(%q) returned error: %v", test.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("lex(%q) = %v, want %v", test.input, got, test.want)
+		}
+	}
+}
+
+func TestLexArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		want []token
+	}{
+		{
+			args: []string{"--name", "Aleksandr Markov", `"quoted"`, `back\slash`},
+			want: []token{
+				{TokenType: typeLongKey, Value: "--name", Offset: 0, End: 6},
+				{TokenType: typeStringValue, Value: "Aleksandr Markov", Offset: 7, End: 23},
+				{TokenType: typeStringValue, Value: `"quoted"`, Offset: 24, End: 32},
+				{TokenType: typeStringValue, Value: `back\slash`, Offset: 33, End: 43},
+			},
+		},
+		{
+			args: []string{"--key=a b", "-k'v'", "--", "--json"},
+			want: []token{
+				{TokenType: typeLongKey, Value: "--key", Offset: 0, End: 5},
+				{TokenType: typeAttachedValue, Value: "a b", Offset: 6, End: 9},
+				{TokenType: typeShortGroup, Value: "-k'v'", Offset: 10, End: 15},
+				{TokenType: typeTerminator, Value: "--", Offset: 16, End: 18},
+				{TokenType: typeStringValue, Value: "--json", Offset: 19, End: 25},
+			},
+		},
+		{
+			args: []string{"-", "-v", "-"},
+			want: []token{
+				{TokenType: typeStringValue, Value: "-", Offset: 0, End: 1},
+				{TokenType: typeShortGroup, Value: "-v", Offset: 2, End: 4},
+				{TokenType: typeStringValue, Value: "-", Offset: 5, End: 6},
+			},
+		},
+		{
+			args: []string{"", "привет"},
+			want: []token{
+				{TokenType: typeStringValue, Value: "", Offset: 0, End: 0},
+				{TokenType: typeStringValue, Value: "привет", Offset: 1, End: 7},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got := lexArgs(test.args)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("lexArgs(%q) = %v, want %v", test.args, got, test.want)
+		}
+	}
+}
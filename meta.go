@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 type fieldsIndex struct {
@@ -13,18 +15,131 @@ type fieldsIndex struct {
 	fieldsByIndex      map[int]*indexEntry
 	positionalsDefault *indexEntry
 	requiredFields     []*indexEntry
+	commands           map[string]*indexEntry
+	// negatedFields resolves --no-<name> keys of negatable flags
+	negatedFields map[string]*indexEntry
+	// groups and relations come from xor, and, requires and conflicts tags
+	groups    []*optionGroup
+	relations []relation
+
+	// entries and commandEntries keep the order of fields in the struct
+	entries        []*indexEntry
+	commandEntries []*indexEntry
+
+	// parent is the index of the command this one is nested into.
+	// Keys of parent commands are available in nested ones.
+	parent *fieldsIndex
+	// path of the struct from the top level one: Subscriptions.Get
+	path string
+	// value is the indexed struct, it's used to call hooks
+	value reflect.Value
+}
+
+// lookupLongName searches for the long key in the index and its parents
+func (index *fieldsIndex) lookupLongName(name string) (*indexEntry, bool) {
+	for current := index; current != nil; current = current.parent {
+		if entry, ok := current.fieldsByLongName[name]; ok {
+			return entry, true
+		}
+	}
+	return nil, false
+}
+
+// lookupShortName searches for the short key in the index and its parents
+func (index *fieldsIndex) lookupShortName(name string) (*indexEntry, bool) {
+	for current := index; current != nil; current = current.parent {
+		if entry, ok := current.fieldsByShortName[name]; ok {
+			return entry, true
+		}
+	}
+	return nil, false
+}
+
+// lookupNegatedName searches for the --no-<name> key in the index and its parents
+func (index *fieldsIndex) lookupNegatedName(name string) (*indexEntry, bool) {
+	for current := index; current != nil; current = current.parent {
+		if entry, ok := current.negatedFields[name]; ok {
+			return entry, true
+		}
+	}
+	return nil, false
 }
 
 type fieldMeta struct {
+	name         string
+	help         string
 	shortName    string
 	longName     string
 	isPositional bool
 	isRequired   bool
+	commandName  string
+	defaultValue string
+	hasDefault   bool
+	envName      string
+	hasEnv       bool
+	layout       string
+	separator    string
+	isUnique     bool
+	isCounter    bool
+	isNegatable  bool
+	choices      []string
+	ignoreCase   bool
+	constraints  constraints
+	xorGroup     string
+	andGroup     string
+	requires     []string
+	conflicts    []string
+}
+
+// displayName returns the name of the argument for messages: long name
+// for named fields and kebab-case field name for positional ones
+func (meta fieldMeta) displayName() string {
+	if meta.longName != "" {
+		return meta.longName
+	}
+	return deriveLongName(meta.name)
+}
+
+// negatedName returns --no-<name> key for the field
+func (meta fieldMeta) negatedName() string {
+	return "--no-" + strings.TrimPrefix(meta.longName, "--")
 }
 
 func getFieldMeta(field reflect.StructField) (fieldMeta, error) {
-	meta := fieldMeta{}
+	meta := fieldMeta{
+		name: field.Name,
+		help: field.Tag.Get("help"),
+	}
+	meta.defaultValue, meta.hasDefault = field.Tag.Lookup("default")
+	meta.envName, meta.hasEnv = field.Tag.Lookup("env")
+	meta.layout = field.Tag.Get("layout")
+	meta.separator = field.Tag.Get("sep")
+	meta.xorGroup = strings.TrimSpace(field.Tag.Get("xor"))
+	meta.andGroup = strings.TrimSpace(field.Tag.Get("and"))
+	meta.requires = splitKeys(field.Tag.Get("requires"))
+	meta.conflicts = splitKeys(field.Tag.Get("conflicts"))
+	if choicesTag, ok := field.Tag.Lookup("choices"); ok {
+		for _, choice := range strings.Split(choicesTag, "|") {
+			meta.choices = append(meta.choices, strings.TrimSpace(choice))
+		}
+	}
 
+	cmdTag, isCommand := field.Tag.Lookup("cmd")
+	if isCommand {
+		if _, ok := field.Tag.Lookup("arg"); ok {
+			return fieldMeta{}, errors.New("command field cannot have arg tag")
+		}
+		if field.Type.Kind() != reflect.Ptr || field.Type.Elem().Kind() != reflect.Struct {
+			return fieldMeta{}, errors.New("command field must be a pointer to a struct")
+		}
+		meta.commandName = strings.TrimSpace(cmdTag)
+		if meta.commandName == "" || strings.HasPrefix(meta.commandName, "-") {
+			return fieldMeta{}, fmt.Errorf("invalid command name: %q", cmdTag)
+		}
+		return meta, nil
+	}
+
+	nonEmpty := false
 	argTag, ok := field.Tag.Lookup("arg")
 	if ok {
 		argTagParts := strings.Split(argTag, ",")
@@ -35,6 +150,16 @@ func getFieldMeta(field reflect.StructField) (fieldMeta, error) {
 				meta.isPositional = true
 			} else if tag == "required" {
 				meta.isRequired = true
+			} else if tag == "unique" {
+				meta.isUnique = true
+			} else if tag == "count" {
+				meta.isCounter = true
+			} else if tag == "negatable" {
+				meta.isNegatable = true
+			} else if tag == "ignorecase" {
+				meta.ignoreCase = true
+			} else if tag == "nonempty" {
+				nonEmpty = true
 			} else if strings.HasPrefix(tag, "--") {
 				meta.longName = tag
 			} else if strings.HasPrefix(tag, "-") {
@@ -45,109 +170,329 @@ func getFieldMeta(field reflect.StructField) (fieldMeta, error) {
 		}
 	}
 
-	if meta.longName == "" && meta.shortName == "" {
-		meta.isPositional = true
+	if meta.isPositional && (meta.shortName != "" || meta.longName != "") {
+		return fieldMeta{}, errors.New("positional field cannot have short or long name")
+	}
+
+	var err error
+	meta.constraints, err = getConstraints(field, nonEmpty)
+	if err != nil {
+		return fieldMeta{}, err
+	}
+
+	if meta.isCounter {
+		switch field.Type.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		default:
+			return fieldMeta{}, errors.New("counter field must be an int")
+		}
+		if meta.isPositional {
+			return fieldMeta{}, errors.New("counter field cannot be positional")
+		}
+	}
+
+	if !meta.isPositional && meta.longName == "" {
+		meta.longName = "--" + deriveLongName(field.Name)
 	}
 
-	if meta.isPositional && (meta.shortName != "" || meta.longName != "") {
-		return fieldMeta{}, fmt.Errorf("positional field cannot have short or long name")
+	if meta.isNegatable {
+		t := field.Type
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Bool || meta.isPositional {
+			return fieldMeta{}, errors.New("negatable field must be a named bool")
+		}
 	}
 
 	return meta, nil
 }
 
+// deriveLongName converts a Go field name to kebab-case the way README describes:
+// the name is split by capital letters, consequent one-letter items are joined
+// (so acronyms like ID or HTTP stay together) and every item is lowercased.
+func deriveLongName(fieldName string) string {
+	items := make([]string, 0)
+	current := []rune{}
+	for _, r := range fieldName {
+		if unicode.IsUpper(r) && len(current) > 0 {
+			items = append(items, string(current))
+			current = []rune{}
+		}
+		current = append(current, r)
+	}
+	if len(current) > 0 {
+		items = append(items, string(current))
+	}
+
+	joined := make([]string, 0, len(items))
+	acronym := ""
+	for _, item := range items {
+		if utf8.RuneCountInString(item) == 1 {
+			acronym += item
+			continue
+		}
+		if acronym != "" {
+			joined = append(joined, acronym)
+			acronym = ""
+		}
+		joined = append(joined, item)
+	}
+	if acronym != "" {
+		joined = append(joined, acronym)
+	}
+
+	for i := range joined {
+		joined[i] = strings.ToLower(joined[i])
+	}
+
+	return strings.Join(joined, "-")
+}
+
 type indexEntry struct {
 	v reflect.Value
 	t reflect.Type
 	m fieldMeta
 
-	presented bool
+	// multiValue is set for slices and maps, which are filled item by item.
+	// Types with their own conversion are single values.
+	multiValue bool
+	// path of the field from the top level struct: Subscriptions.Get.UserID
+	path string
+	// defaultValue is the value of default tag converted to the field type
+	defaultValue reflect.Value
+
+	presented   bool
+	source      Source
+	occurrences []Occurrence
 }
 
+// preinit replaces nil slices and maps with empty ones. Pre-filled values are
+// kept as default values, consumeValue drops them once the value is passed.
 func preinit(entry *indexEntry) {
-	if entry.v.Kind() == reflect.Slice {
-		entry.v.Set(reflect.MakeSlice(entry.v.Type(), 0, 0))
+	if entry.multiValue && entry.v.IsNil() {
+		resetMultiValue(entry)
 	}
 }
 
-func buildIndex(v any) (fieldsIndex, error) {
-	index := fieldsIndex{
+// preinitIndex prepares fields of the index for parsing. It's not a part of
+// buildIndex since the index is also built for rendering help, which must not
+// modify the struct.
+func preinitIndex(index *fieldsIndex) {
+	for _, entry := range index.entries {
+		preinit(entry)
+	}
+}
+
+func (p *Parser) buildIndex(v any) (*fieldsIndex, error) {
+	if err := validateInput(v); err != nil {
+		return nil, err
+	}
+
+	return p.buildStructIndex(reflect.ValueOf(v).Elem(), nil, "")
+}
+
+// buildStructIndex indexes fields of the struct value. Parent is the index of
+// the command the struct is nested into, nil for the top level struct.
+func (p *Parser) buildStructIndex(rv reflect.Value, parent *fieldsIndex, path string) (*fieldsIndex, error) {
+	index := &fieldsIndex{
 		fieldsByLongName:  make(map[string]*indexEntry),
 		fieldsByShortName: make(map[string]*indexEntry),
 		fieldsByIndex:     make(map[int]*indexEntry),
+		commands:          make(map[string]*indexEntry),
+		negatedFields:     make(map[string]*indexEntry),
+		parent:            parent,
+		path:              path,
+		value:             rv,
 	}
 
-	if err := validateInput(v); err != nil {
+	positionalIndex := 0
+	if err := p.indexFields(index, rv, path, &positionalIndex); err != nil {
+		return index, err
+	}
+
+	// negated keys are registered after all fields, since explicit keys have priority
+	for _, entry := range index.entries {
+		if !entry.m.isNegatable && !(p.NegatableFlags && canBeNegated(entry)) {
+			continue
+		}
+		name := entry.m.negatedName()
+		if _, ok := index.fieldsByLongName[name]; ok {
+			if entry.m.isNegatable {
+				return index, &DefinitionError{Field: entry.path, Err: fmt.Errorf("multiple fields for one key: %s", name)}
+			}
+			continue
+		}
+		entry.m.isNegatable = true
+		index.negatedFields[name] = entry
+	}
+
+	if err := index.resolveRelations(); err != nil {
 		return index, err
 	}
 
-	rt := reflect.TypeOf(v).Elem()
-	rv := reflect.ValueOf(v).Elem()
+	return index, nil
+}
+
+// indexFields adds fields of the struct value to the index. Fields of
+// embedded structs are added as if they were declared in the struct itself.
+func (p *Parser) indexFields(index *fieldsIndex, rv reflect.Value, path string, positionalIndex *int) error {
+	rt := rv.Type()
 	numFields := rt.NumField()
-	positionalIndex := 0
 
 	for i := 0; i < numFields; i++ {
 		field := rt.Field(i)
+		fieldPath := joinPath(path, field.Name)
 		if !field.IsExported() {
-			return index, fmt.Errorf("field %s is not exported", field.Name)
+			return &DefinitionError{Field: fieldPath, Err: errors.New("field is not exported")}
 		}
 
 		fv := rv.FieldByIndex(field.Index)
+		if embedded, err := p.isEmbeddedStruct(field); err != nil {
+			return &DefinitionError{Field: fieldPath, Err: err}
+		} else if embedded {
+			if err := p.indexFields(index, fv, fieldPath, positionalIndex); err != nil {
+				return err
+			}
+			continue
+		}
+
 		fm, err := getFieldMeta(field)
 		if err != nil {
-			return index, err
+			return &DefinitionError{Field: fieldPath, Err: err}
 		}
 
 		entry := &indexEntry{
-			v: fv,
-			t: field.Type,
-			m: fm,
+			v:          fv,
+			t:          field.Type,
+			m:          fm,
+			multiValue: (field.Type.Kind() == reflect.Slice || field.Type.Kind() == reflect.Map) && !p.isCustomType(field.Type),
+			path:       fieldPath,
 		}
 
-		preinit(entry)
+		if fm.commandName != "" {
+			if _, ok := index.commands[fm.commandName]; ok {
+				return &DefinitionError{Field: fieldPath, Err: fmt.Errorf("multiple fields for one command: %s", fm.commandName)}
+			}
+			index.commands[fm.commandName] = entry
+			index.commandEntries = append(index.commandEntries, entry)
+			continue
+		}
+
+		index.entries = append(index.entries, entry)
+
+		if err := p.convertBounds(entry); err != nil {
+			return err
+		}
+		if fm.hasDefault {
+			if err := p.convertDefault(entry); err != nil {
+				return err
+			}
+		}
 
 		if fm.longName != "" {
 			if _, ok := index.fieldsByLongName[fm.longName]; ok {
-				return index, fmt.Errorf("multiple fields for one key: %s", fm.longName)
+				return &DefinitionError{Field: fieldPath, Err: fmt.Errorf("multiple fields for one key: %s", fm.longName)}
 			}
 			index.fieldsByLongName[fm.longName] = entry
 		}
 		if fm.shortName != "" {
 			if _, ok := index.fieldsByShortName[fm.shortName]; ok {
-				return index, fmt.Errorf("multiple fields for one key: %s", fm.shortName)
+				return &DefinitionError{Field: fieldPath, Err: fmt.Errorf("multiple fields for one key: %s", fm.shortName)}
 			}
 			index.fieldsByShortName[fm.shortName] = entry
 		}
 		if fm.isPositional {
-			if fv.Kind() == reflect.Slice {
+			if entry.multiValue {
 				if index.positionalsDefault != nil {
-					return index, fmt.Errorf("multiple positional default fields are not supported")
+					return &DefinitionError{Field: fieldPath, Err: errors.New("multiple positional default fields are not supported")}
 				}
 				index.positionalsDefault = entry
 			} else {
-				index.fieldsByIndex[positionalIndex] = entry
-				positionalIndex++
+				index.fieldsByIndex[*positionalIndex] = entry
+				*positionalIndex++
 			}
 		}
 		if fm.isRequired {
 			index.requiredFields = append(index.requiredFields, entry)
 		}
+		if fm.xorGroup != "" {
+			index.addToGroup(fm.xorGroup, true, entry)
+		}
+		if fm.andGroup != "" {
+			index.addToGroup(fm.andGroup, false, entry)
+		}
 	}
 
-	return index, nil
+	return nil
+}
+
+// isEmbeddedStruct checks if the field is an embedded struct without tags,
+// which is not a type with its own conversion
+func (p *Parser) isEmbeddedStruct(field reflect.StructField) (bool, error) {
+	if !field.Anonymous || p.isCustomType(field.Type) {
+		return false, nil
+	}
+	if _, ok := field.Tag.Lookup("arg"); ok {
+		return false, nil
+	}
+	if _, ok := field.Tag.Lookup("cmd"); ok {
+		return false, nil
+	}
+
+	if field.Type.Kind() == reflect.Ptr && field.Type.Elem().Kind() == reflect.Struct {
+		return false, errors.New("embedded pointer to struct is not supported, embed the struct itself")
+	}
+	return field.Type.Kind() == reflect.Struct, nil
+}
+
+// canBeNegated checks if the field is a named bool flag, which may have --no-<name> key
+func canBeNegated(entry *indexEntry) bool {
+	t := entry.t
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Bool && !entry.m.isPositional
+}
+
+// enterCommand allocates the struct of the command (unless it's already allocated)
+// and builds the index for it
+func (p *Parser) enterCommand(index *fieldsIndex, command *indexEntry) (*fieldsIndex, error) {
+	if command.v.IsNil() {
+		command.v.Set(reflect.New(command.t.Elem()))
+	}
+	command.presented = true
+
+	commandIndex, err := p.buildStructIndex(command.v.Elem(), index, command.path)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.callBeforeParse(commandIndex); err != nil {
+		return nil, err
+	}
+	preinitIndex(commandIndex)
+
+	return commandIndex, nil
+}
+
+func joinPath(path string, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
 }
 
 func validateInput(v any) error {
 	rv := reflect.ValueOf(v)
 	if rv.Kind() != reflect.Ptr {
-		return errors.New("input must be a pointer")
+		return &DefinitionError{Err: errors.New("input must be a pointer")}
 	}
 	if rv.IsNil() {
-		return errors.New("input must not be nil")
+		return &DefinitionError{Err: errors.New("input must not be nil")}
 	}
 	rv = rv.Elem()
 	if rv.Kind() != reflect.Struct {
-		return errors.New("input must be a pointer to a struct")
+		return &DefinitionError{Err: errors.New("input must be a pointer to a struct")}
 	}
 
 	return nil
@@ -0,0 +1,26 @@
+package argoparser
+
+import "testing"
+
+func TestDeriveLongName(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: "A", want: "a"},
+		{input: "FieldName", want: "field-name"},
+		{input: "UserID", want: "user-id"},
+		{input: "HTTPServer", want: "http-server"},
+		{input: "ServeHTTP", want: "serve-http"},
+		{input: "JSON", want: "json"},
+		{input: "Value1", want: "value1"},
+		{input: "Ключ", want: "ключ"},
+	}
+
+	for _, test := range tests {
+		got := deriveLongName(test.input)
+		if got != test.want {
+			t.Errorf("deriveLongName(%q) = %q, want %q", test.input, got, test.want)
+		}
+	}
+}
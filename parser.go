@@ -1,6 +1,7 @@
 package argoparser
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -9,203 +10,693 @@ import (
 	"strings"
 )
 
+// isFlag checks if the field doesn't require a value: bools and counters
 func isFlag(entry *indexEntry) bool {
-	return entry.t.Kind() == reflect.Bool
+	if entry.m.isCounter {
+		return true
+	}
+	t := entry.t
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Bool
+}
+
+// setFlag sets the flag to true, allocating it for *bool fields.
+// Counters are incremented instead, the first occurrence drops pre-filled value.
+func setFlag(entry *indexEntry) {
+	if entry.m.isCounter {
+		if entry.presented {
+			entry.v.SetInt(entry.v.Int() + 1)
+		} else {
+			entry.v.SetInt(1)
+		}
+		entry.presented = true
+		return
+	}
+
+	target := entry.v
+	if target.Kind() == reflect.Ptr {
+		if target.IsNil() {
+			target.Set(reflect.New(entry.t.Elem()))
+		}
+		target = target.Elem()
+	}
+	target.SetBool(true)
+	entry.presented = true
 }
 
 func isMultiValue(entry *indexEntry) bool {
-	return entry.t.Kind() == reflect.Slice
+	return entry.multiValue
 }
 
-func consumeValue(entry *indexEntry, value string) error {
-	castTo := entry.t
-	if isMultiValue(entry) {
-		castTo = entry.t.Elem()
+func isMap(entry *indexEntry) bool {
+	return entry.t.Kind() == reflect.Map
+}
+
+// resetMultiValue replaces the value of slice or map field with the empty one
+func resetMultiValue(entry *indexEntry) {
+	if isMap(entry) {
+		entry.v.Set(reflect.MakeMap(entry.t))
+	} else {
+		entry.v.Set(reflect.MakeSlice(entry.t, 0, 0))
 	}
+}
 
-	var valueToAppend any
-	var err error
-	if castTo.Kind() == reflect.Int {
-		valueToAppend, err = strconv.Atoi(value)
-		if err != nil {
-			return fmt.Errorf("invalid value for int: %s", value)
+// checkChoice validates the value against choices tag of the field and returns
+// the value as it's written in the tag, which matters for ignorecase option
+func checkChoice(meta fieldMeta, value string) (string, error) {
+	if len(meta.choices) == 0 {
+		return value, nil
+	}
+
+	for _, choice := range meta.choices {
+		if choice == value || (meta.ignoreCase && strings.EqualFold(choice, value)) {
+			return choice, nil
 		}
-	} else if castTo.Kind() == reflect.String {
-		valueToAppend = value
-	} else {
-		return fmt.Errorf("unsupported type: %s", castTo.Kind())
 	}
 
-	if isMultiValue(entry) {
-		entry.v.Set(reflect.Append(entry.v, reflect.ValueOf(valueToAppend)))
-	} else {
-		entry.v.Set(reflect.ValueOf(valueToAppend))
+	message := fmt.Sprintf("%q is not one of %s", value, strings.Join(meta.choices, "|"))
+	if suggestion, ok := suggestChoice(meta, value); ok {
+		message += fmt.Sprintf(", did you mean %q?", suggestion)
+	}
+	return "", errors.New(message)
+}
+
+// consumeValue converts and stores the value of the field. Key and position
+// describe where the value came from for errors.
+func (p *Parser) consumeValue(entry *indexEntry, key string, value string, position int) error {
+	if err := p.storeValue(entry, value); err != nil {
+		var definitionErr *DefinitionError
+		if errors.As(err, &definitionErr) {
+			return &DefinitionError{Field: entry.path, Err: definitionErr.Err}
+		}
+		// the value is passed even though it's invalid, so in CollectErrors
+		// mode the field isn't reported as missing
+		entry.presented = true
+		return &InvalidValueError{
+			Field:    entry.path,
+			Option:   entry.m.displayName(),
+			Key:      key,
+			Value:    value,
+			Position: position,
+			Err:      err,
+			Location: noLocation,
+		}
 	}
 
 	entry.presented = true
 	return nil
 }
 
-func (p *Parser) checkRequiredFields(index fieldsIndex) error {
-	for _, entry := range index.requiredFields {
+// storeValue checks the value against choices and constraints and stores it
+// to the field, appending it for slices and maps
+func (p *Parser) storeValue(entry *indexEntry, value string) error {
+	if !isMap(entry) {
+		checked, err := checkChoice(entry.m, value)
+		if err != nil {
+			return err
+		}
+		value = checked
+	}
+
+	if isMultiValue(entry) {
 		if !entry.presented {
-			// TODO: change error text
-			return fmt.Errorf("required field is not presented: %s", entry.m.longName)
+			// drop the pre-filled default value
+			resetMultiValue(entry)
+		}
+		if isMap(entry) {
+			if err := p.consumeMapItem(entry, value); err != nil {
+				return err
+			}
+		} else {
+			item := reflect.New(entry.t.Elem()).Elem()
+			if err := p.setValue(item, value, entry.m); err != nil {
+				return err
+			}
+			if err := checkConstraints(entry.m, item); err != nil {
+				return err
+			}
+			entry.v.Set(reflect.Append(entry.v, item))
+		}
+	} else {
+		if err := p.setValue(entry.v, value, entry.m); err != nil {
+			return err
+		}
+		if err := checkConstraints(entry.m, entry.v); err != nil {
+			return err
 		}
 	}
+
 	return nil
 }
 
-func (p *Parser) parseImpl(tokens []token, result any) error {
-	index, err := buildIndex(result)
+// consumeMapItem splits the value like key=value by the separator of the
+// field and stores converted value by converted key
+func (p *Parser) consumeMapItem(entry *indexEntry, value string) error {
+	separator := entry.m.separator
+	if separator == "" {
+		separator = "="
+	}
+
+	rawKey, rawItem, ok := strings.Cut(value, separator)
+	if !ok {
+		return fmt.Errorf("%q is not in key%svalue format", value, separator)
+	}
+
+	key := reflect.New(entry.t.Key()).Elem()
+	if err := p.setValue(key, rawKey, entry.m); err != nil {
+		return fmt.Errorf("invalid key: %w", err)
+	}
+	if entry.m.isUnique && entry.v.MapIndex(key).IsValid() {
+		return fmt.Errorf("duplicate key: %s", rawKey)
+	}
+
+	rawItem, err := checkChoice(entry.m, rawItem)
 	if err != nil {
 		return err
 	}
 
-	tokenPos := 0
+	item := reflect.New(entry.t.Elem()).Elem()
+	if err := p.setValue(item, rawItem, entry.m); err != nil {
+		return err
+	}
+	if err := checkConstraints(entry.m, item); err != nil {
+		return err
+	}
 
-	positionalPos := 0
+	entry.v.SetMapIndex(key, item)
+	return nil
+}
 
-	for tokenPos < len(tokens) {
-		token := tokens[tokenPos]
+// consumeJoinedValue consumes the value which is not split to tokens, like
+// environment variable or default tag. Values for slices and maps are
+// separated by comma. Key is the name of environment variable, if any.
+func (p *Parser) consumeJoinedValue(entry *indexEntry, key string, value string) error {
+	if !isMultiValue(entry) {
+		return p.consumeValue(entry, key, value, -1)
+	}
 
-		switch token.TokenType {
-		case typeLongKey:
-			entry, ok := index.fieldsByLongName[token.Value]
-			if !ok {
-				if p.SkipUnknown {
-					tokenPos++
-					continue
-				}
-				return fmt.Errorf("unknown long key: %s", token.Value)
-			}
+	resetMultiValue(entry)
+	if value == "" {
+		return nil
+	}
+	for _, item := range strings.Split(value, ",") {
+		if err := p.consumeValue(entry, key, item, -1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-			if isFlag(entry) {
-				entry.v.SetBool(true)
-				entry.presented = true
-				tokenPos++
-				continue
+// envName returns the name of environment variable for the field, if any.
+// Explicit name from env tag is used as is. For fields with empty env tag or
+// for all named fields if EnvPrefix is set the name is derived from the long
+// name: --user-id with prefix APP_ becomes APP_USER_ID.
+func (p *Parser) envName(entry *indexEntry) (string, bool) {
+	if entry.m.envName != "" {
+		return entry.m.envName, true
+	}
+	if (!entry.m.hasEnv && p.EnvPrefix == "") || entry.m.longName == "" {
+		return "", false
+	}
+
+	name := strings.TrimPrefix(entry.m.longName, "--")
+	name = strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+	return p.EnvPrefix + name, true
+}
+
+// applyEnv sets values from environment variables to the fields which were not
+// presented. Such fields are considered presented. Values for slices are
+// separated by comma.
+func (p *Parser) applyEnv(index *fieldsIndex, errs *errorCollector) {
+	for _, entry := range index.entries {
+		if entry.presented {
+			continue
+		}
+		name, ok := p.envName(entry)
+		if !ok {
+			continue
+		}
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+
+		if err := p.consumeJoinedValue(entry, name, value); err != nil {
+			if !errs.add(fmt.Errorf("invalid value in environment variable %s: %w", name, err)) {
+				return
 			}
+			continue
+		}
+		entry.presented = true
+		entry.source = SourceEnv
+		entry.occurrences = []Occurrence{{Key: name, Value: value, Position: -1}}
+	}
+}
 
-			if tokenPos+1 >= len(tokens) {
-				return fmt.Errorf("missing value for flag: %s", token.Value)
+// convertDefault converts the value of default tag while the struct is indexed,
+// so a malformed tag is reported even if the field is passed
+func (p *Parser) convertDefault(entry *indexEntry) error {
+	converted := &indexEntry{
+		v:          reflect.New(entry.t).Elem(),
+		t:          entry.t,
+		m:          entry.m,
+		multiValue: entry.multiValue,
+		path:       entry.path,
+	}
+	if err := p.consumeJoinedValue(converted, "", entry.m.defaultValue); err != nil {
+		var definitionErr *DefinitionError
+		if errors.As(err, &definitionErr) {
+			return definitionErr
+		}
+		var invalid *InvalidValueError
+		if errors.As(err, &invalid) {
+			err = invalid.Err
+		}
+		return &DefinitionError{Field: entry.path, Err: fmt.Errorf("invalid default value: %w", err)}
+	}
+
+	entry.defaultValue = converted.v
+	return nil
+}
+
+// applyDefaults sets values from default tag to the fields which were not presented.
+// Values for slices are separated by comma.
+func applyDefaults(index *fieldsIndex) {
+	for _, entry := range index.entries {
+		if entry.presented || !entry.m.hasDefault {
+			continue
+		}
+
+		entry.v.Set(entry.defaultValue)
+		entry.source = SourceDefault
+		entry.occurrences = []Occurrence{{Value: entry.m.defaultValue, Position: -1}}
+	}
+}
+
+func (p *Parser) checkRequiredFields(index *fieldsIndex, errs *errorCollector) {
+	for _, entry := range index.requiredFields {
+		if !entry.presented {
+			if !errs.add(&MissingRequiredError{Field: entry.path, Option: entry.m.displayName()}) {
+				return
 			}
+		}
+	}
+}
+
+// checkNegationConflict returns error if the negatable flag is passed both
+// as --<name> (or its short key) and --no-<name>
+func checkNegationConflict(entry *indexEntry, key string) error {
+	if !entry.m.isNegatable {
+		return nil
+	}
+	negatedName := entry.m.negatedName()
+	for _, occurrence := range entry.occurrences {
+		if (occurrence.Key == negatedName) != (key == negatedName) {
+			return &ConflictError{Options: []string{occurrence.Key, key}}
+		}
+	}
+	return nil
+}
 
-			nextToken := tokens[tokenPos+1]
+// countAttached returns 1 if the key is followed by the attached value, which
+// is consumed with the key even if the key is invalid
+func countAttached(rest []token) int {
+	if len(rest) > 0 && rest[0].TokenType == typeAttachedValue {
+		return 1
+	}
+	return 0
+}
 
-			if err := consumeValue(entry, nextToken.Value); err != nil {
-				return err
+// unexpectedValueError is returned for the value attached to the key which
+// doesn't take values: --no-color=false, --verbose=3
+func unexpectedValueError(entry *indexEntry, key string, value string, position int) error {
+	return &InvalidValueError{
+		Field:    entry.path,
+		Option:   entry.m.displayName(),
+		Key:      key,
+		Value:    value,
+		Position: position,
+		Err:      fmt.Errorf("%s does not accept a value", key),
+		Location: noLocation,
+	}
+}
+
+// consumeNegatedKey sets the negatable flag to false for --no-<name> key.
+// Like consumeKey, it returns the number of tokens it used.
+func (p *Parser) consumeNegatedKey(entry *indexEntry, key string, rest []token, position int) (int, error) {
+	if len(rest) > 0 && rest[0].TokenType == typeAttachedValue {
+		return 1, unexpectedValueError(entry, key, rest[0].Value, position)
+	}
+	if err := checkNegationConflict(entry, key); err != nil {
+		return 0, err
+	}
+
+	target := entry.v
+	if target.Kind() == reflect.Ptr {
+		target.Set(reflect.New(entry.t.Elem()))
+		target = target.Elem()
+	}
+	target.SetBool(false)
+	entry.presented = true
+	entry.record(key, "", position)
+	return 0, nil
+}
+
+// consumeKey applies the key to the flag or value entry. The value is taken from
+// the rest of tokens, so the function returns the number of tokens it used,
+// even if the value is invalid. Position is the position of the key in the input.
+func (p *Parser) consumeKey(entry *indexEntry, key string, rest []token, position int) (int, error) {
+	attached := countAttached(rest)
+	hasAttachedValue := attached > 0
+
+	if isFlag(entry) {
+		if entry.m.isCounter && hasAttachedValue {
+			return 1, unexpectedValueError(entry, key, rest[0].Value, position)
+		}
+		if err := checkNegationConflict(entry, key); err != nil {
+			return attached, err
+		}
+
+		hasBoolValue := hasAttachedValue
+		if p.BoolValues && !entry.m.isCounter && len(rest) > 0 && rest[0].TokenType == typeStringValue {
+			_, err := strconv.ParseBool(rest[0].Value)
+			hasBoolValue = err == nil
+		}
+		if hasBoolValue {
+			if err := p.consumeValue(entry, key, rest[0].Value, position); err != nil {
+				return 1, err
 			}
+			entry.record(key, rest[0].Value, position)
+			return 1, nil
+		}
+		setFlag(entry)
+		entry.record(key, "", position)
+		return 0, nil
+	}
 
-			tokenPos++
-		case typeShortGroup:
-			if len(token.Value) > 2 {
-				flags := token.Value[1:]
-				for _, flag := range flags {
-					entry, ok := index.fieldsByShortName["-"+string(flag)]
-					if !ok {
-						if p.SkipUnknown {
-							tokenPos++
-							continue
-						}
-						return fmt.Errorf("unknown short key: %s", string(flag))
-					}
-					if !isFlag(entry) {
-						return fmt.Errorf("value for field is flag, but field is not a flag: %s", "-"+string(flag))
-					}
-					entry.v.SetBool(true)
-					entry.presented = true
+	if len(rest) == 0 {
+		return 0, &MissingValueError{Field: entry.path, Key: key, Position: position}
+	}
+
+	if err := p.consumeValue(entry, key, rest[0].Value, position); err != nil {
+		return 1, err
+	}
+	entry.record(key, rest[0].Value, position)
+
+	return 1, nil
+}
+
+// consumeShortGroup handles -a, -abc and -kVALUE forms. Every key of the group
+// but the last one must be a flag, unless it is followed by its attached value.
+func (p *Parser) consumeShortGroup(index *fieldsIndex, group string, rest []token, position int) (int, error) {
+	keys := []rune(group)[1:]
+	attached := countAttached(rest)
+
+	for i, key := range keys {
+		name := "-" + string(key)
+		isLast := i == len(keys)-1
+
+		entry, ok := index.lookupShortName(name)
+		if !ok {
+			if name == helpShortName {
+				return 0, ErrHelp
+			}
+			if p.SkipUnknown {
+				if isLast {
+					return attached, nil
 				}
-				tokenPos++
 				continue
 			}
+			return attached, &UnknownOptionError{
+				Key:        name,
+				Position:   position,
+				Suggestion: suggestShortKey(index, group, name),
+			}
+		}
+
+		if isLast {
+			return p.consumeKey(entry, name, rest, position)
+		}
+
+		if isFlag(entry) {
+			if err := checkNegationConflict(entry, name); err != nil {
+				return attached, err
+			}
+			setFlag(entry)
+			entry.record(name, "", position)
+			continue
+		}
+
+		// the rest of the group is the value: -l10, quoted part is glued to it: -l1"0"
+		value := string(keys[i+1:])
+		if attached > 0 {
+			value += rest[0].Value
+		}
+		if err := p.consumeValue(entry, name, value, position); err != nil {
+			return attached, err
+		}
+		entry.record(name, value, position)
+		return attached, nil
+	}
+
+	return 0, nil
+}
+
+// consumePositional stores the value to the next positional field or to the
+// positionals default one if all of them are already filled.
+func (p *Parser) consumePositional(index *fieldsIndex, positionalPos *int, value string, position int) error {
+	if positionalByIndex, ok := index.fieldsByIndex[*positionalPos]; ok {
+		if err := p.consumeValue(positionalByIndex, "", value, position); err != nil {
+			return err
+		}
+		positionalByIndex.record("", value, position)
+		*positionalPos++
+		return nil
+	}
+
+	if index.positionalsDefault != nil {
+		if err := p.consumeValue(index.positionalsDefault, "", value, position); err != nil {
+			return err
+		}
+		index.positionalsDefault.record("", value, position)
+		return nil
+	}
+
+	if p.SkipUnknown {
+		return nil
+	}
+	return &UnknownOptionError{
+		Value:      value,
+		Position:   position,
+		Suggestion: suggestCommand(index, value),
+	}
+}
+
+func (p *Parser) parseImpl(tokens []token, result any) (*Result, error) {
+	if err := validateInput(result); err != nil {
+		return nil, err
+	}
+	index, err := p.buildIndex(result)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.callBeforeParse(index); err != nil {
+		return nil, err
+	}
+	preinitIndex(index)
+
+	tokenPos := 0
+
+	positionalPos := 0
 
-			entry, ok := index.fieldsByShortName[token.Value]
-			// the code below is copypasted from long-key parsing
-			// TODO: move to common place
+	terminated := false
+
+	// indexes of the top level struct and every command met
+	commandIndexes := []*fieldsIndex{index}
+
+	errs := &errorCollector{collect: p.CollectErrors}
+
+	// fail locates the error at the current token and the tokens consumed
+	// with it, then reports whether parsing may go on
+	fail := func(err error, consumed int) bool {
+		locateError(err, tokens[tokenPos:tokenPos+consumed+1])
+		return errs.add(err)
+	}
+
+	for tokenPos < len(tokens) {
+		token := tokens[tokenPos]
+
+		if terminated {
+			if err := p.consumePositional(index, &positionalPos, token.Value, tokenPos); err != nil && !fail(err, 0) {
+				return nil, err
+			}
+			tokenPos++
+			continue
+		}
+
+		switch token.TokenType {
+		case typeLongKey:
+			entry, ok := index.lookupLongName(token.Value)
 			if !ok {
+				if negated, ok := index.lookupNegatedName(token.Value); ok {
+					consumed, err := p.consumeNegatedKey(negated, token.Value, tokens[tokenPos+1:], tokenPos)
+					if err != nil && !fail(err, consumed) {
+						return nil, err
+					}
+					tokenPos += consumed
+					break
+				}
+				if token.Value == helpLongName {
+					return nil, ErrHelp
+				}
+				// the attached value goes away with the unknown key
+				attached := countAttached(tokens[tokenPos+1:])
 				if p.SkipUnknown {
-					tokenPos++
+					tokenPos += attached + 1
 					continue
 				}
-				return fmt.Errorf("unknown short key: %s", token.Value)
+				err := &UnknownOptionError{
+					Key:        token.Value,
+					Position:   tokenPos,
+					Suggestion: suggestLongKey(index, token.Value),
+				}
+				if !fail(err, attached) {
+					return nil, err
+				}
+				tokenPos += attached
+				break
 			}
 
-			if isFlag(entry) {
-				entry.v.SetBool(true)
-				entry.presented = true
-				tokenPos++
-				continue
+			consumed, err := p.consumeKey(entry, token.Value, tokens[tokenPos+1:], tokenPos)
+			if err != nil && !fail(err, consumed) {
+				return nil, err
 			}
-
-			if tokenPos+1 >= len(tokens) {
-				return fmt.Errorf("missing value for flag: %s", token.Value)
+			tokenPos += consumed
+		case typeShortGroup:
+			consumed, err := p.consumeShortGroup(index, token.Value, tokens[tokenPos+1:], tokenPos)
+			if err == ErrHelp {
+				return nil, err
 			}
-
-			nextToken := tokens[tokenPos+1]
-
-			if err := consumeValue(entry, nextToken.Value); err != nil {
-				return err
+			if err != nil && !fail(err, consumed) {
+				return nil, err
 			}
-
-			tokenPos++
+			tokenPos += consumed
+		case typeTerminator:
+			terminated = true
 		case typeStringValue:
-			if positionalByIndex, ok := index.fieldsByIndex[positionalPos]; ok {
-				if err := consumeValue(positionalByIndex, token.Value); err != nil {
-					return err
-				}
-				positionalPos++
-			} else {
-				if index.positionalsDefault != nil {
-					if err := consumeValue(index.positionalsDefault, token.Value); err != nil {
-						return err
-					}
-				} else {
-					if p.SkipUnknown {
-						tokenPos++
-						continue
-					}
-					return fmt.Errorf("unexpected positional parameter: %s", token.Value)
+			if command, ok := index.commands[token.Value]; ok {
+				index, err = p.enterCommand(index, command)
+				if err != nil {
+					return nil, err
 				}
+				command.record("", token.Value, tokenPos)
+				commandIndexes = append(commandIndexes, index)
+				positionalPos = 0
+				break
+			}
+			if err := p.consumePositional(index, &positionalPos, token.Value, tokenPos); err != nil && !fail(err, 0) {
+				return nil, err
+			}
+		default:
+			// attached values are consumed with their keys, so this one has
+			// no key to belong to
+			err := &UnknownOptionError{Value: token.Value, Position: tokenPos}
+			if !fail(err, 0) {
+				return nil, err
 			}
 		}
 
 		tokenPos++
 	}
 
-	if err := p.checkRequiredFields(index); err != nil {
-		return err
+	for _, commandIndex := range commandIndexes {
+		p.applyEnv(commandIndex, errs)
+		p.checkRequiredFields(commandIndex, errs)
+		checkGroups(commandIndex, errs)
+		applyDefaults(commandIndex)
+		for _, entry := range commandIndex.entries {
+			if entry.source != SourceNone && isMultiValue(entry) {
+				if err := checkCount(entry); err != nil {
+					errs.add(err)
+				}
+			}
+		}
+	}
+	if err := errs.result(); err != nil {
+		return nil, err
 	}
 
-	return nil
+	if err := p.callAfterParse(commandIndexes); err != nil {
+		return nil, err
+	}
+
+	return buildResult(commandIndexes), nil
 }
 
 type Parser struct {
 	SkipUnknown bool
+
+	// Name of the program for the usage line. Base name of os.Args[0] is used if empty.
+	Name string
+
+	// EnvPrefix enables environment variables for all named fields. Names of
+	// variables are derived from long names: --user-id becomes EnvPrefix+"USER_ID".
+	EnvPrefix string
+
+	// NegatableFlags adds --no-<name> keys for all named bool fields,
+	// like negatable option of arg tag does for a single field
+	NegatableFlags bool
+
+	// BoolValues allows flags to take the next argument as value if it is a
+	// valid bool: --json false. Attached values like --json=false are always allowed.
+	BoolValues bool
+
+	// CollectErrors makes parsing go on after errors, so all unknown options,
+	// invalid values and missing fields are returned at once as ErrorList
+	CollectErrors bool
+
+	// LenientQuotes makes ParseString accept unclosed quotes and backslash at
+	// the end of the input: the quoted value just ends with the input.
+	// Without it such input fails with SyntaxError.
+	LenientQuotes bool
+
+	converters map[reflect.Type]Converter
 }
 
 func (p *Parser) ParseString(input string, result any) error {
-	tokens := lex(input)
+	_, err := p.ParseStringWithResult(input, result)
+	return err
+}
+
+// ParseStringWithResult works like ParseString and also describes how every field was set
+func (p *Parser) ParseStringWithResult(input string, result any) (*Result, error) {
+	tokens, err := lex(input, p.LenientQuotes)
+	if err != nil {
+		return nil, err
+	}
 	return p.parseImpl(tokens, result)
 }
 
 func (p *Parser) ParseSlice(input []string, result any) error {
-	return p.ParseString(strings.Join(input, " "), result)
+	_, err := p.ParseSliceWithResult(input, result)
+	return err
+}
+
+// ParseSliceWithResult works like ParseSlice and also describes how every field was set
+func (p *Parser) ParseSliceWithResult(input []string, result any) (*Result, error) {
+	return p.parseImpl(lexArgs(input), result)
 }
 
 func (p *Parser) ParseAppArgs(result any) error {
-	tokens := []token{}
-	for _, arg := range os.Args[1:] {
-		if strings.HasPrefix(arg, "--") {
-			tokens = append(tokens, token{TokenType: typeLongKey, Value: arg})
-		} else if strings.HasPrefix(arg, "-") {
-			tokens = append(tokens, token{TokenType: typeShortGroup, Value: arg})
-		} else {
-			tokens = append(tokens, token{TokenType: typeStringValue, Value: arg})
-		}
-	}
+	_, err := p.ParseAppArgsWithResult(result)
+	return err
+}
 
-	return p.parseImpl(tokens, result)
+// ParseAppArgsWithResult works like ParseAppArgs and also describes how every field was set
+func (p *Parser) ParseAppArgsWithResult(result any) (*Result, error) {
+	return p.parseImpl(lexArgs(os.Args[1:]), result)
 }
 
 func (p *Parser) ParseReader(reader *io.Reader, result any) error {
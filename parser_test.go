@@ -1,6 +1,8 @@
 package argoparser
 
 import (
+	"errors"
+	"os"
 	"reflect"
 	"strings"
 	"testing"
@@ -271,7 +273,7 @@ func TestParser(t *testing.T) {
 			Name:  "Test unsupported type error",
 			Input: "--value 123",
 			Result: struct {
-				Value float64 `arg:"--value"`
+				Value struct{ A int } `arg:"--value"`
 			}{},
 			ShouldReturnError: true,
 		},
@@ -507,6 +509,27 @@ func TestParser(t *testing.T) {
 			}{
 				Flag: false,
 			},
+		},
+		{
+			Name:  "Terminator: everything after -- is positional",
+			Input: "--flag pos -- -5 --flag -- 'a b'",
+			Result: struct {
+				Flag    bool     `arg:"--flag"`
+				First   string   `arg:"positional"`
+				Second  int      `arg:"positional"`
+				Default []string `arg:"positional"`
+			}{
+				Flag: true, First: "pos", Second: -5, Default: []string{"--flag", "--", "a b"},
+			},
+		},
+		{
+			Name:  "Terminator: unexpected positional after it",
+			Input: "-- --flag",
+			Result: struct {
+				Flag bool `arg:"--flag"`
+			}{
+				Flag: true,
+			},
 			ShouldReturnError: true,
 		},
 		{
@@ -593,17 +616,117 @@ func TestParser(t *testing.T) {
 			},
 		},
 		{
-			Name:  "Positional without tag",
-			Input: "a b -c d",
+			Name:  "Implicit long names",
+			Input: "--a a --user-id 5 --http-server b -c d",
+			Result: struct {
+				A          string
+				UserID     int
+				HTTPServer string
+				C          bool   `arg:"-c"`
+				D          string `arg:"positional"`
+			}{
+				A: "a", UserID: 5, HTTPServer: "b", C: true, D: "d",
+			},
+		},
+		{
+			Name:  "Implicit long name for field with short name only",
+			Input: "--value-c 1 -c 2",
+			Result: struct {
+				ValueC []string `arg:"-c"`
+			}{
+				ValueC: []string{"1", "2"},
+			},
+		},
+		{
+			Name:  "Untagged field is not positional",
+			Input: "a",
 			Result: struct {
 				A string
-				B string
-				C bool   `arg:"-c"`
-				D string `arg:"positional"`
+			}{},
+			ShouldReturnError: true,
+		},
+		{
+			Name:  "Implicit long name conflicts with explicit one",
+			Input: "",
+			Result: struct {
+				UserID int
+				Other  int `arg:"--user-id"`
+			}{},
+			ShouldReturnError: true,
+		},
+		{
+			Name:  "Attached values for long keys",
+			Input: `--env=production --name="Aleksandr Markov" --limit=10 --empty= --tag=a=b`,
+			Result: struct {
+				Env   string `arg:"--env"`
+				Name  string `arg:"--name"`
+				Limit int    `arg:"--limit"`
+				Empty string `arg:"--empty"`
+				Tag   string `arg:"--tag"`
+			}{
+				Env: "production", Name: "Aleksandr Markov", Limit: 10, Empty: "", Tag: "a=b",
+			},
+		},
+		{
+			Name:  "Attached values for short keys",
+			Input: `-l10 -vn"Aleksandr Markov" -xe-5`,
+			Result: struct {
+				Limit   int    `arg:"-l"`
+				Verbose bool   `arg:"-v"`
+				Name    string `arg:"-n"`
+				X       bool   `arg:"-x"`
+				Offset  int    `arg:"-e"`
+			}{
+				Limit: 10, Verbose: true, Name: "Aleksandr Markov", X: true, Offset: -5,
+			},
+		},
+		{
+			Name:  "Quoted part glued to the value in short group",
+			Input: `-l1"0" -vnAleksandr" Markov"`,
+			Result: struct {
+				Limit   int    `arg:"-l"`
+				Verbose bool   `arg:"-v"`
+				Name    string `arg:"-n"`
+			}{
+				Limit: 10, Verbose: true, Name: "Aleksandr Markov",
+			},
+		},
+		{
+			Name:  "Lone hyphen is a positional value",
+			Input: "- --out - a -",
+			Result: struct {
+				Out     string   `arg:"--out"`
+				Default []string `arg:"positional"`
+			}{
+				Out: "-", Default: []string{"-", "a", "-"},
+			},
+		},
+		{
+			Name:  "Flags in group followed by separated value",
+			Input: "-vl 10",
+			Result: struct {
+				Verbose bool `arg:"-v"`
+				Limit   int  `arg:"-l"`
 			}{
-				A: "a", B: "b", C: true, D: "d",
+				Verbose: true, Limit: 10,
 			},
 		},
+		{
+			Name:  "Attached value for a flag error",
+			Input: "--json=yes",
+			Result: struct {
+				JSON bool `arg:"--json"`
+			}{},
+			ShouldReturnError: true,
+		},
+		{
+			Name:  "Attached value for a short flag error",
+			Input: `-j"yes"`,
+			Result: struct {
+				JSON bool `arg:"-j"`
+			}{},
+			ShouldReturnError: true,
+		},
 		{
 			Name:  "Unexported field",
 			Input: "",
@@ -619,6 +742,743 @@ func TestParser(t *testing.T) {
 	}
 }
 
+type testGetCmd struct {
+	UserID int  `arg:"--user-id,-u,required"`
+	Active bool `arg:"--only-active"`
+}
+
+type testCancelCmd struct {
+	ID string `arg:"positional,required"`
+}
+
+type testSubscriptionsCmd struct {
+	Limit  int            `arg:"-l"`
+	Get    *testGetCmd    `cmd:"get"`
+	Cancel *testCancelCmd `cmd:"cancel"`
+}
+
+type testBanCmd struct {
+	Users []string `arg:"positional"`
+}
+
+type testUsersCmd struct {
+	Ban *testBanCmd `cmd:"ban"`
+}
+
+type testCommandsArgs struct {
+	Verbose       bool                  `arg:"-v"`
+	Subscriptions *testSubscriptionsCmd `cmd:"subscriptions"`
+	Users         *testUsersCmd         `cmd:"users"`
+}
+
+func TestCommands(t *testing.T) {
+	tc := []TestCase{
+		{
+			Name:   "No command",
+			Input:  "-v",
+			Result: testCommandsArgs{Verbose: true},
+		},
+		{
+			Name:  "Nested command with inherited flags",
+			Input: "-v subscriptions -l 10 get -u 5 --only-active",
+			Result: testCommandsArgs{
+				Verbose: true,
+				Subscriptions: &testSubscriptionsCmd{
+					Limit: 10,
+					Get:   &testGetCmd{UserID: 5, Active: true},
+				},
+			},
+		},
+		{
+			Name:  "Parent flags are available after nested command",
+			Input: "subscriptions get -u 5 -l 10 -v",
+			Result: testCommandsArgs{
+				Verbose: true,
+				Subscriptions: &testSubscriptionsCmd{
+					Limit: 10,
+					Get:   &testGetCmd{UserID: 5},
+				},
+			},
+		},
+		{
+			Name:  "Command with positionals",
+			Input: "users ban alice bob",
+			Result: testCommandsArgs{
+				Users: &testUsersCmd{
+					Ban: &testBanCmd{Users: []string{"alice", "bob"}},
+				},
+			},
+		},
+		{
+			Name:  "Command positional required",
+			Input: "subscriptions cancel",
+			Result: testCommandsArgs{
+				Subscriptions: &testSubscriptionsCmd{Cancel: &testCancelCmd{}},
+			},
+			ShouldReturnError: true,
+		},
+		{
+			Name:  "Command keys are scoped",
+			Input: "-u 5 subscriptions get",
+			Result: testCommandsArgs{
+				Subscriptions: &testSubscriptionsCmd{Get: &testGetCmd{UserID: 5}},
+			},
+			ShouldReturnError: true,
+		},
+		{
+			Name:  "Nested command required field",
+			Input: "subscriptions get",
+			Result: testCommandsArgs{
+				Subscriptions: &testSubscriptionsCmd{Get: &testGetCmd{}},
+			},
+			ShouldReturnError: true,
+		},
+		{
+			Name:  "Unknown command",
+			Input: "subscriptions list",
+			Result: testCommandsArgs{
+				Subscriptions: &testSubscriptionsCmd{},
+			},
+			ShouldReturnError: true,
+		},
+		{
+			Name:  "Command field must be a pointer to a struct",
+			Input: "",
+			Result: struct {
+				Get testGetCmd `cmd:"get"`
+			}{},
+			ShouldReturnError: true,
+		},
+		{
+			Name:  "Multiple fields for one command",
+			Input: "",
+			Result: struct {
+				Get  *testGetCmd `cmd:"get"`
+				Get2 *testGetCmd `cmd:"get"`
+			}{},
+			ShouldReturnError: true,
+		},
+	}
+
+	for _, testCase := range tc {
+		impl(t, testCase, false)
+	}
+}
+
+func TestDefaults(t *testing.T) {
+	tc := []TestCase{
+		{
+			Name:  "Default values are applied to not presented fields",
+			Input: "--passed 5",
+			Result: struct {
+				Passed int      `arg:"--passed" default:"1"`
+				Env    string   `arg:"--env" default:"testing"`
+				Limit  int      `arg:"-l" default:"10"`
+				JSON   bool     `arg:"--json" default:"true"`
+				Tags   []string `arg:"--tag" default:"a,b"`
+				IDs    []int    `arg:"--id" default:"1,2"`
+				Empty  []string `arg:"--empty" default:""`
+				Pos    string   `arg:"positional" default:"pos"`
+			}{
+				Passed: 5, Env: "testing", Limit: 10, JSON: true,
+				Tags: []string{"a", "b"}, IDs: []int{1, 2}, Empty: []string{}, Pos: "pos",
+			},
+		},
+		{
+			Name:  "Passed values replace default slices",
+			Input: "--tag c --tag d",
+			Result: struct {
+				Tags []string `arg:"--tag" default:"a,b"`
+			}{
+				Tags: []string{"c", "d"},
+			},
+		},
+		{
+			Name:  "Default value for command field",
+			Input: "get",
+			Result: struct {
+				Get *struct {
+					Limit int `arg:"-l" default:"10"`
+				} `cmd:"get"`
+			}{
+				Get: &struct {
+					Limit int `arg:"-l" default:"10"`
+				}{Limit: 10},
+			},
+		},
+		{
+			Name:  "Default value doesn't satisfy required",
+			Input: "",
+			Result: struct {
+				Env string `arg:"--env,required" default:"testing"`
+			}{
+				Env: "testing",
+			},
+			ShouldReturnError: true,
+		},
+		{
+			Name:  "Invalid default value",
+			Input: "",
+			Result: struct {
+				Limit int `arg:"-l" default:"ten"`
+			}{
+				Limit: 10,
+			},
+			ShouldReturnError: true,
+		},
+	}
+
+	for _, testCase := range tc {
+		impl(t, testCase, false)
+	}
+
+	t.Run("Pre-filled slice is kept when not passed", func(t *testing.T) {
+		result := struct {
+			Tags  []string `arg:"--tag"`
+			Other []string `arg:"--other"`
+		}{
+			Tags:  []string{"a", "b"},
+			Other: []string{"c"},
+		}
+
+		parser := Parser{}
+		if err := parser.ParseString("--other d", &result); err != nil {
+			t.Fatalf("ParseString failed: %s", err)
+		}
+
+		if !reflect.DeepEqual(result.Tags, []string{"a", "b"}) {
+			t.Fatalf("expected pre-filled tags, got %v", result.Tags)
+		}
+		if !reflect.DeepEqual(result.Other, []string{"d"}) {
+			t.Fatalf("expected passed value to replace pre-filled one, got %v", result.Other)
+		}
+	})
+}
+
+func TestEnv(t *testing.T) {
+	t.Setenv("ARGO_TEST_USER_ID", "5")
+	t.Setenv("ARGO_TEST_TAGS", "a,b")
+	t.Setenv("ARGO_TEST_JSON", "true")
+	t.Setenv("ARGO_TEST_REGION", "eu")
+	t.Setenv("APP_ENV", "production")
+	t.Setenv("APP_LIMIT", "ten")
+
+	t.Run("Explicit variable names", func(t *testing.T) {
+		result := struct {
+			UserID int      `arg:"--user-id,required" env:"ARGO_TEST_USER_ID"`
+			Tags   []string `arg:"--tag" env:"ARGO_TEST_TAGS"`
+			JSON   bool     `arg:"--json" env:"ARGO_TEST_JSON"`
+			Env    string   `arg:"--env" env:"ARGO_TEST_ENV" default:"testing"`
+		}{}
+
+		parser := Parser{}
+		if err := parser.ParseString("", &result); err != nil {
+			t.Fatalf("ParseString failed: %s", err)
+		}
+		if result.UserID != 5 || !reflect.DeepEqual(result.Tags, []string{"a", "b"}) || !result.JSON || result.Env != "testing" {
+			t.Fatalf("unexpected result: %+v", result)
+		}
+	})
+
+	t.Run("Passed values win", func(t *testing.T) {
+		result := struct {
+			UserID int `arg:"--user-id" env:"ARGO_TEST_USER_ID"`
+		}{}
+
+		parser := Parser{}
+		if err := parser.ParseString("--user-id 7", &result); err != nil {
+			t.Fatalf("ParseString failed: %s", err)
+		}
+		if result.UserID != 7 {
+			t.Fatalf("expected 7, got %d", result.UserID)
+		}
+	})
+
+	t.Run("Names derived with EnvPrefix", func(t *testing.T) {
+		result := struct {
+			Env string `arg:"--env"`
+		}{}
+
+		parser := Parser{EnvPrefix: "APP_"}
+		if err := parser.ParseString("", &result); err != nil {
+			t.Fatalf("ParseString failed: %s", err)
+		}
+		if result.Env != "production" {
+			t.Fatalf("expected production, got %s", result.Env)
+		}
+	})
+
+	t.Run("Names derived for empty env tag", func(t *testing.T) {
+		result := struct {
+			Region string `arg:"--argo-test-region" env:""`
+		}{}
+
+		parser := Parser{}
+		if err := parser.ParseString("", &result); err != nil {
+			t.Fatalf("ParseString failed: %s", err)
+		}
+		if result.Region != "eu" {
+			t.Fatalf("expected eu, got %s", result.Region)
+		}
+	})
+
+	t.Run("Invalid value mentions variable name", func(t *testing.T) {
+		result := struct {
+			Limit int `arg:"--limit"`
+		}{}
+
+		parser := Parser{EnvPrefix: "APP_"}
+		err := parser.ParseString("", &result)
+		if err == nil || !strings.Contains(err.Error(), "APP_LIMIT") {
+			t.Fatalf("expected error mentioning APP_LIMIT, got %v", err)
+		}
+	})
+}
+
+func TestMaps(t *testing.T) {
+	tc := []TestCase{
+		{
+			Name:  "Map values",
+			Input: "--label team=core --label env=prod --limit a=1 --limit b=-2 --flag x=true --eq k=a=b",
+			Result: struct {
+				Labels map[string]string `arg:"--label"`
+				Limits map[string]int    `arg:"--limit"`
+				Flags  map[string]bool   `arg:"--flag"`
+				Eq     map[string]string `arg:"--eq"`
+				Empty  map[string]string `arg:"--empty"`
+			}{
+				Labels: map[string]string{"team": "core", "env": "prod"},
+				Limits: map[string]int{"a": 1, "b": -2},
+				Flags:  map[string]bool{"x": true},
+				Eq:     map[string]string{"k": "a=b"},
+				Empty:  map[string]string{},
+			},
+		},
+		{
+			Name:  "Map with custom separator and converted keys",
+			Input: "--port 80:http --port 443:https",
+			Result: struct {
+				Ports map[int]string `arg:"--port" sep:":"`
+			}{
+				Ports: map[int]string{80: "http", 443: "https"},
+			},
+		},
+		{
+			Name:  "Map default and later values override",
+			Input: "--label a=2",
+			Result: struct {
+				Labels map[string]string `arg:"--label" default:"a=1,b=1"`
+				Other  map[string]string `arg:"--other" default:"a=1,b=1"`
+			}{
+				Labels: map[string]string{"a": "2"},
+				Other:  map[string]string{"a": "1", "b": "1"},
+			},
+		},
+		{
+			Name:  "Map value without separator",
+			Input: "--label team",
+			Result: struct {
+				Labels map[string]string `arg:"--label"`
+			}{},
+			ShouldReturnError: true,
+		},
+		{
+			Name:  "Map invalid key",
+			Input: "--port http=80",
+			Result: struct {
+				Ports map[int]string `arg:"--port"`
+			}{},
+			ShouldReturnError: true,
+		},
+		{
+			Name:  "Map invalid value",
+			Input: "--limit a=b",
+			Result: struct {
+				Limits map[string]int `arg:"--limit"`
+			}{},
+			ShouldReturnError: true,
+		},
+		{
+			Name:  "Map duplicate keys are allowed by default",
+			Input: "--label a=1 --label a=2",
+			Result: struct {
+				Labels map[string]string `arg:"--label"`
+			}{
+				Labels: map[string]string{"a": "2"},
+			},
+		},
+		{
+			Name:  "Map duplicate keys are rejected for unique",
+			Input: "--label a=1 --label a=2",
+			Result: struct {
+				Labels map[string]string `arg:"--label,unique"`
+			}{
+				Labels: map[string]string{"a": "2"},
+			},
+			ShouldReturnError: true,
+		},
+	}
+
+	for _, testCase := range tc {
+		impl(t, testCase, false)
+	}
+}
+
+func TestCounters(t *testing.T) {
+	tc := []TestCase{
+		{
+			Name:  "Counters in groups and separate keys",
+			Input: "-vvv --verbose -qv -d",
+			Result: struct {
+				Verbose int  `arg:"--verbose,-v,count"`
+				Quiet   int  `arg:"-q,count"`
+				Debug   bool `arg:"-d"`
+				Level   int8 `arg:"--level,count"`
+			}{
+				Verbose: 5, Quiet: 1, Debug: true, Level: 0,
+			},
+		},
+		{
+			Name:  "Counter doesn't take attached value",
+			Input: "-vvv --verbose=10",
+			Result: struct {
+				Verbose int `arg:"--verbose,-v,count"`
+			}{
+				Verbose: 3,
+			},
+			ShouldReturnError: true,
+		},
+		{
+			Name:  "Counter doesn't take attached value in short group",
+			Input: `-v"3"`,
+			Result: struct {
+				Verbose int `arg:"--verbose,-v,count"`
+			}{},
+			ShouldReturnError: true,
+		},
+		{
+			Name:  "Counter takes default when not passed",
+			Input: "",
+			Result: struct {
+				Verbose int `arg:"-v,count" default:"2"`
+			}{
+				Verbose: 2,
+			},
+		},
+		{
+			Name:  "Counter doesn't take separated value",
+			Input: "-v 3",
+			Result: struct {
+				Verbose int `arg:"-v,count"`
+			}{
+				Verbose: 1,
+			},
+			ShouldReturnError: true,
+		},
+		{
+			Name:  "Counter must be an int",
+			Input: "",
+			Result: struct {
+				Verbose string `arg:"-v,count"`
+			}{},
+			ShouldReturnError: true,
+		},
+		{
+			Name:  "Counter can't be positional",
+			Input: "",
+			Result: struct {
+				Verbose int `arg:"positional,count"`
+			}{},
+			ShouldReturnError: true,
+		},
+	}
+
+	for _, testCase := range tc {
+		impl(t, testCase, false)
+	}
+
+	t.Run("Pre-filled counter is replaced", func(t *testing.T) {
+		result := struct {
+			Verbose int `arg:"-v,count"`
+		}{
+			Verbose: 5,
+		}
+		parser := Parser{BoolValues: true}
+		if err := parser.ParseString("-vv", &result); err != nil {
+			t.Fatalf("ParseString failed: %s", err)
+		}
+		if result.Verbose != 2 {
+			t.Fatalf("expected 2, got %d", result.Verbose)
+		}
+	})
+}
+
+func TestNegatableFlags(t *testing.T) {
+	tc := []TestCase{
+		{
+			Name:  "Negated flags are set to false",
+			Input: "--no-color --no-cache --json --no-verbose",
+			Result: struct {
+				Color   bool  `arg:"--color,negatable" default:"true"`
+				Cache   *bool `arg:"--cache,negatable"`
+				JSON    bool  `arg:"--json,negatable"`
+				Verbose bool  `arg:"-v,negatable"`
+			}{
+				Color: false, Cache: new(bool), JSON: true, Verbose: false,
+			},
+		},
+		{
+			Name:  "Not negatable flag",
+			Input: "--no-color",
+			Result: struct {
+				Color bool `arg:"--color"`
+			}{},
+			ShouldReturnError: true,
+		},
+		{
+			Name:  "Both forms conflict",
+			Input: "--color --no-color",
+			Result: struct {
+				Color bool `arg:"--color,negatable"`
+			}{},
+			ShouldReturnError: true,
+		},
+		{
+			Name:  "Both forms conflict with short key in group",
+			Input: "--no-color -vc",
+			Result: struct {
+				Color   bool `arg:"--color,-c,negatable"`
+				Verbose bool `arg:"-v"`
+			}{},
+			ShouldReturnError: true,
+		},
+		{
+			Name:  "Negated flag doesn't take value",
+			Input: "--no-color=true",
+			Result: struct {
+				Color bool `arg:"--color,negatable"`
+			}{},
+			ShouldReturnError: true,
+		},
+		{
+			Name:  "Negatable field must be a bool",
+			Input: "",
+			Result: struct {
+				Color string `arg:"--color,negatable"`
+			}{},
+			ShouldReturnError: true,
+		},
+		{
+			Name:  "Negated key conflicts with explicit field",
+			Input: "",
+			Result: struct {
+				Color   bool `arg:"--color,negatable"`
+				NoColor bool `arg:"--no-color"`
+			}{},
+			ShouldReturnError: true,
+		},
+	}
+
+	for _, testCase := range tc {
+		impl(t, testCase, false)
+	}
+
+	t.Run("Parser-wide negatable flags", func(t *testing.T) {
+		result := struct {
+			Color   bool `arg:"--color" default:"true"`
+			Cache   bool `arg:"--cache"`
+			NoCache bool `arg:"--no-cache"`
+		}{}
+		parser := Parser{NegatableFlags: true}
+		if err := parser.ParseString("--no-color --no-cache", &result); err != nil {
+			t.Fatalf("ParseString failed: %s", err)
+		}
+		if result.Color || result.Cache || !result.NoCache {
+			t.Fatalf("unexpected result: %+v", result)
+		}
+	})
+}
+
+func TestChoices(t *testing.T) {
+	tc := []TestCase{
+		{
+			Name:  "Valid choices",
+			Input: "--env staging --format JSON --level 2 --tag a --tag b --label x=on prod",
+			Result: struct {
+				Env    string            `arg:"--env" choices:"testing|staging|production"`
+				Format string            `arg:"--format,ignorecase" choices:"json|table|csv"`
+				Level  int               `arg:"--level" choices:"1|2|3"`
+				Tags   []string          `arg:"--tag" choices:"a|b"`
+				Labels map[string]string `arg:"--label" choices:"on|off"`
+				Target string            `arg:"positional" choices:"dev|prod"`
+			}{
+				Env: "staging", Format: "json", Level: 2, Tags: []string{"a", "b"},
+				Labels: map[string]string{"x": "on"}, Target: "prod",
+			},
+		},
+		{
+			Name:  "Choices are case sensitive by default",
+			Input: "--env STAGING",
+			Result: struct {
+				Env string `arg:"--env" choices:"testing|staging|production"`
+			}{},
+			ShouldReturnError: true,
+		},
+		{
+			Name:  "Invalid slice item",
+			Input: "--tag a --tag c",
+			Result: struct {
+				Tags []string `arg:"--tag" choices:"a|b"`
+			}{},
+			ShouldReturnError: true,
+		},
+		{
+			Name:  "Invalid map value",
+			Input: "--label x=maybe",
+			Result: struct {
+				Labels map[string]string `arg:"--label" choices:"on|off"`
+			}{},
+			ShouldReturnError: true,
+		},
+		{
+			Name:  "Invalid default value",
+			Input: "",
+			Result: struct {
+				Env string `arg:"--env" choices:"testing|production" default:"staging"`
+			}{},
+			ShouldReturnError: true,
+		},
+	}
+
+	for _, testCase := range tc {
+		impl(t, testCase, false)
+	}
+
+	t.Run("Error lists choices and suggests the closest one", func(t *testing.T) {
+		result := struct {
+			Env string `arg:"--env" choices:"testing|staging|production"`
+		}{}
+		parser := Parser{}
+
+		err := parser.ParseString("--env prodution", &result)
+		expected := `invalid value for --env: "prodution" is not one of testing|staging|production, did you mean "production"?`
+		if err == nil || err.Error() != expected {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		err = parser.ParseString("--env local", &result)
+		expected = `invalid value for --env: "local" is not one of testing|staging|production`
+		if err == nil || err.Error() != expected {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Suggestion ignores case of choices with ignorecase", func(t *testing.T) {
+		result := struct {
+			Format string `arg:"--format,ignorecase" choices:"JSON|CSV|Table"`
+		}{}
+		parser := Parser{}
+
+		err := parser.ParseString("--format jsn", &result)
+		expected := `invalid value for --format: "jsn" is not one of JSON|CSV|Table, did you mean "JSON"?`
+		if err == nil || err.Error() != expected {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		err = parser.ParseString("--format TABEL", &result)
+		expected = `invalid value for --format: "TABEL" is not one of JSON|CSV|Table, did you mean "Table"?`
+		if err == nil || err.Error() != expected {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+// EmbeddedTestOptions is embedded into test structs, embedded types must be
+// exported like any other field
+type EmbeddedTestOptions struct {
+	Verbose bool   `arg:"-v"`
+	Config  string `arg:"--config" default:"app.yaml"`
+	Input   string `arg:"positional"`
+}
+
+type EmbeddedTestNested struct {
+	EmbeddedTestOptions
+	Limit int `arg:"--limit"`
+}
+
+func TestEmbeddedStructs(t *testing.T) {
+	tc := []TestCase{
+		{
+			Name:  "Fields of embedded struct are options of the struct",
+			Input: "-v in out --name x",
+			Result: struct {
+				EmbeddedTestOptions
+				Name   string `arg:"--name"`
+				Output string `arg:"positional"`
+			}{
+				EmbeddedTestOptions: EmbeddedTestOptions{Verbose: true, Config: "app.yaml", Input: "in"},
+				Name:                "x",
+				Output:              "out",
+			},
+		},
+		{
+			Name:  "Embedded structs may be nested",
+			Input: "--limit 5 --config c.yaml",
+			Result: struct {
+				EmbeddedTestNested
+			}{
+				EmbeddedTestNested: EmbeddedTestNested{
+					EmbeddedTestOptions: EmbeddedTestOptions{Config: "c.yaml"},
+					Limit:               5,
+				},
+			},
+		},
+		{
+			Name:  "Keys of embedded struct must be unique",
+			Input: "",
+			Result: struct {
+				EmbeddedTestOptions
+				Config string `arg:"--config"`
+			}{},
+			ShouldReturnError: true,
+		},
+		{
+			Name:  "Embedded pointer to struct is not supported",
+			Input: "",
+			Result: struct {
+				*EmbeddedTestOptions
+			}{},
+			ShouldReturnError: true,
+		},
+		{
+			Name:  "Embedded struct with arg tag is an option",
+			Input: "--options x",
+			Result: struct {
+				EmbeddedTestOptions `arg:"--options"`
+			}{},
+			ShouldReturnError: true,
+		},
+	}
+
+	for _, testCase := range tc {
+		impl(t, testCase, false)
+	}
+
+	t.Run("Paths of embedded fields", func(t *testing.T) {
+		args := struct {
+			EmbeddedTestOptions
+		}{}
+		parser := Parser{}
+		result, err := parser.ParseStringWithResult("-v", &args)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if !result.IsSet("EmbeddedTestOptions.Verbose") {
+			t.Fatalf("expected EmbeddedTestOptions.Verbose to be set, got %v", result.Fields())
+		}
+	})
+}
+
 func TestParseSlice(t *testing.T) {
 	t.Run("Test ParseSlice with string array", func(t *testing.T) {
 		input := []string{"pos1", "--flag1", "--value1", "val1"}
@@ -692,6 +1552,87 @@ func TestParseSlice(t *testing.T) {
 			t.Fatalf("expected %v, got %v", expected, result)
 		}
 	})
+
+	t.Run("Test ParseSlice with lone hyphen", func(t *testing.T) {
+		result := struct {
+			File    string   `arg:"--file"`
+			Default []string `arg:"positional"`
+		}{}
+
+		parser := Parser{}
+		if err := parser.ParseSlice([]string{"-", "a", "--file", "-"}, &result); err != nil {
+			t.Fatalf("ParseSlice failed: %s", err)
+		}
+		if result.File != "-" || !reflect.DeepEqual(result.Default, []string{"-", "a"}) {
+			t.Fatalf("unexpected result: %+v", result)
+		}
+	})
+
+	t.Run("Test ParseSlice keeps element boundaries", func(t *testing.T) {
+		type Args struct {
+			Name    string   `arg:"--name"`
+			Query   string   `arg:"--query"`
+			Default []string `arg:"positional"`
+		}
+
+		input := []string{"--name", "Aleksandr Markov", `--query="it's"`, `C:\Users\`, `"unclosed`}
+		result := Args{}
+
+		parser := Parser{}
+		if err := parser.ParseSlice(input, &result); err != nil {
+			t.Fatalf("ParseSlice failed: %s", err)
+		}
+
+		expected := Args{
+			Name:    "Aleksandr Markov",
+			Query:   `"it's"`,
+			Default: []string{`C:\Users\`, `"unclosed`},
+		}
+		if !reflect.DeepEqual(result, expected) {
+			t.Fatalf("expected %v, got %v", expected, result)
+		}
+	})
+}
+
+func TestParseAttachedValueWithoutKey(t *testing.T) {
+	args := struct {
+		Name string `arg:"positional"`
+	}{}
+
+	parser := Parser{}
+	_, err := parser.parseImpl([]token{{TokenType: typeAttachedValue, Value: "x"}}, &args)
+	var unknown *UnknownOptionError
+	if !errors.As(err, &unknown) || unknown.Value != "x" {
+		t.Fatalf("expected UnknownOptionError for x, got %v", err)
+	}
+}
+
+func TestParseAppArgs(t *testing.T) {
+	type Args struct {
+		Env     string   `arg:"--env"`
+		Limit   int      `arg:"-l"`
+		JSON    bool     `arg:"--json"`
+		Default []string `arg:"positional"`
+	}
+
+	args := os.Args
+	defer func() { os.Args = args }()
+	os.Args = []string{"app", "--env=production", "-l10", "a b", "--", "--json", "-l"}
+
+	result := Args{}
+	parser := Parser{}
+	if err := parser.ParseAppArgs(&result); err != nil {
+		t.Fatalf("ParseAppArgs failed: %s", err)
+	}
+
+	expected := Args{
+		Env:     "production",
+		Limit:   10,
+		Default: []string{"a b", "--json", "-l"},
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatalf("expected %v, got %v", expected, result)
+	}
 }
 
 func TestInputValidation(t *testing.T) {
@@ -754,6 +1695,17 @@ func TestSkipUnknown(t *testing.T) {
 			},
 			ShouldReturnError: false,
 		},
+		{
+			Name:  "Test SkipUnknown: attached values are skipped with the key",
+			Input: "--unknown=value -xvalue -u=v --env e",
+			Result: struct {
+				Env     string   `arg:"--env"`
+				Default []string `arg:"positional"`
+			}{
+				Env:     "e",
+				Default: []string{},
+			},
+		},
 	}
 
 	for _, testCase := range testcases {
@@ -762,3 +1714,36 @@ func TestSkipUnknown(t *testing.T) {
 		})
 	}
 }
+
+func TestLenientQuotes(t *testing.T) {
+	type args struct {
+		Name string `arg:"--name"`
+	}
+
+	input := `--name "it's me`
+
+	strict := Parser{}
+	err := strict.ParseString(input, &args{})
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("expected SyntaxError, got %v", err)
+	}
+
+	lenient := Parser{LenientQuotes: true}
+	result := args{}
+	if err := lenient.ParseString(input, &result); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Name != "it's me" {
+		t.Fatalf("expected name %q, got %q", "it's me", result.Name)
+	}
+
+	// the opened quote is an empty value, like --name="" in strict mode
+	result = args{Name: "default"}
+	if err := lenient.ParseString(`--name="`, &result); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Name != "" {
+		t.Fatalf("expected empty name, got %q", result.Name)
+	}
+}
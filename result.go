@@ -0,0 +1,110 @@
+package argoparser
+
+// Source tells where the value of the field came from
+type Source int
+
+const (
+	SourceNone    Source = iota // the field was not set
+	SourceArgs                  // the field was passed in the input
+	SourceEnv                   // the field was taken from environment variable
+	SourceDefault               // the field was set from default tag
+)
+
+func (s Source) String() string {
+	switch s {
+	case SourceArgs:
+		return "args"
+	case SourceEnv:
+		return "env"
+	case SourceDefault:
+		return "default"
+	}
+	return "none"
+}
+
+// Occurrence is a single appearance of the field value
+type Occurrence struct {
+	// Key as it was passed: --user-id, -u. Name of environment variable for
+	// values from environment. Empty for positional arguments and defaults.
+	Key string
+	// Value is the raw value, empty for flags without value
+	Value string
+	// Position is the index of the token in the input, -1 for values not from the input.
+	// For ParseString it's the index of space-separated item, --key=value counts as two items.
+	Position int
+}
+
+// FieldResult describes how the field was set during parsing
+type FieldResult struct {
+	// Name is the path of the field in the struct: Limit, Subscriptions.Get.UserID
+	Name        string
+	Source      Source
+	Occurrences []Occurrence
+}
+
+// IsSet checks if the field was set from any source
+func (f *FieldResult) IsSet() bool {
+	return f.Source != SourceNone
+}
+
+// Result describes fields of the struct after parsing. Fields of commands
+// which were not passed are not presented in the result.
+type Result struct {
+	fields []*FieldResult
+	byName map[string]*FieldResult
+}
+
+// Fields returns results for all fields: fields of the top level struct
+// first, then fields of passed commands
+func (r *Result) Fields() []*FieldResult {
+	return r.fields
+}
+
+// Field returns the result for the field by its path like Subscriptions.Get.UserID
+func (r *Result) Field(name string) (*FieldResult, bool) {
+	field, ok := r.byName[name]
+	return field, ok
+}
+
+// IsSet checks if the field was set from any source. False for unknown fields.
+func (r *Result) IsSet(name string) bool {
+	field, ok := r.byName[name]
+	return ok && field.IsSet()
+}
+
+// record remembers the value of the entry passed in the input
+func (entry *indexEntry) record(key string, value string, position int) {
+	entry.source = SourceArgs
+	entry.occurrences = append(entry.occurrences, Occurrence{
+		Key:      key,
+		Value:    value,
+		Position: position,
+	})
+}
+
+func buildResult(indexes []*fieldsIndex) *Result {
+	result := &Result{
+		byName: make(map[string]*FieldResult),
+	}
+
+	add := func(entry *indexEntry) {
+		field := &FieldResult{
+			Name:        entry.path,
+			Source:      entry.source,
+			Occurrences: entry.occurrences,
+		}
+		result.fields = append(result.fields, field)
+		result.byName[field.Name] = field
+	}
+
+	for _, index := range indexes {
+		for _, entry := range index.entries {
+			add(entry)
+		}
+		for _, entry := range index.commandEntries {
+			add(entry)
+		}
+	}
+
+	return result
+}
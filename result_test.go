@@ -0,0 +1,100 @@
+package argoparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseWithResult(t *testing.T) {
+	t.Setenv("ARGO_TEST_TOKEN", "secret")
+
+	type GetCmd struct {
+		UserID int `arg:"--user-id,-u"`
+		Active bool
+	}
+	type Args struct {
+		Limit   int      `arg:"-l"`
+		Env     string   `arg:"--env" default:"testing"`
+		Token   string   `arg:"--token" env:"ARGO_TEST_TOKEN"`
+		Verbose bool     `arg:"-v"`
+		JSON    bool     `arg:"--json"`
+		Tags    []string `arg:"--tag"`
+		Get     *GetCmd  `cmd:"get"`
+		Other   *GetCmd  `cmd:"other"`
+	}
+
+	args := Args{}
+	parser := Parser{}
+	result, err := parser.ParseStringWithResult("-l 0 --tag=a -v --tag b get -u5", &args)
+	if err != nil {
+		t.Fatalf("ParseStringWithResult failed: %s", err)
+	}
+
+	expected := map[string]FieldResult{
+		"Limit": {Name: "Limit", Source: SourceArgs, Occurrences: []Occurrence{
+			{Key: "-l", Value: "0", Position: 0},
+		}},
+		"Env": {Name: "Env", Source: SourceDefault, Occurrences: []Occurrence{
+			{Value: "testing", Position: -1},
+		}},
+		"Token": {Name: "Token", Source: SourceEnv, Occurrences: []Occurrence{
+			{Key: "ARGO_TEST_TOKEN", Value: "secret", Position: -1},
+		}},
+		"Verbose": {Name: "Verbose", Source: SourceArgs, Occurrences: []Occurrence{
+			{Key: "-v", Position: 4},
+		}},
+		"JSON": {Name: "JSON", Source: SourceNone},
+		"Tags": {Name: "Tags", Source: SourceArgs, Occurrences: []Occurrence{
+			{Key: "--tag", Value: "a", Position: 2},
+			{Key: "--tag", Value: "b", Position: 5},
+		}},
+		"Get": {Name: "Get", Source: SourceArgs, Occurrences: []Occurrence{
+			{Value: "get", Position: 7},
+		}},
+		"Other": {Name: "Other", Source: SourceNone},
+		"Get.UserID": {Name: "Get.UserID", Source: SourceArgs, Occurrences: []Occurrence{
+			{Key: "-u", Value: "5", Position: 8},
+		}},
+		"Get.Active": {Name: "Get.Active", Source: SourceNone},
+	}
+
+	if len(result.Fields()) != len(expected) {
+		t.Fatalf("expected %d fields, got %d", len(expected), len(result.Fields()))
+	}
+	for name, want := range expected {
+		got, ok := result.Field(name)
+		if !ok {
+			t.Errorf("field %s is not in result", name)
+			continue
+		}
+		if !reflect.DeepEqual(*got, want) {
+			t.Errorf("field %s: expected %+v, got %+v", name, want, *got)
+		}
+	}
+
+	if !result.IsSet("Limit") || result.IsSet("JSON") || result.IsSet("Other.UserID") {
+		t.Errorf("unexpected IsSet results")
+	}
+	if _, ok := result.Field("Other.UserID"); ok {
+		t.Errorf("fields of not passed commands must not be in result")
+	}
+}
+
+func TestParseSliceWithResult(t *testing.T) {
+	args := struct {
+		Pos  string   `arg:"positional"`
+		Rest []string `arg:"positional"`
+	}{}
+
+	parser := Parser{}
+	result, err := parser.ParseSliceWithResult([]string{"a", "b", "c"}, &args)
+	if err != nil {
+		t.Fatalf("ParseSliceWithResult failed: %s", err)
+	}
+
+	rest, _ := result.Field("Rest")
+	expected := []Occurrence{{Value: "b", Position: 1}, {Value: "c", Position: 2}}
+	if !reflect.DeepEqual(rest.Occurrences, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, rest.Occurrences)
+	}
+}
@@ -0,0 +1,129 @@
+package argoparser
+
+import (
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// levenshtein returns edit distance between two strings counted in runes
+func levenshtein(a, b string) int {
+	ra := []rune(a)
+	rb := []rune(b)
+
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(rb)]
+}
+
+// suggest returns the candidate closest to the value, if it's close enough
+// to be a typo: at most a third of the value length, but not less than 2 edits
+func suggest(value string, candidates []string) (string, bool) {
+	maxDistance := max(2, utf8.RuneCountInString(value)/3)
+
+	best := ""
+	bestDistance := maxDistance + 1
+	for _, candidate := range candidates {
+		distance := levenshtein(value, candidate)
+		if distance < bestDistance {
+			best = candidate
+			bestDistance = distance
+		}
+	}
+
+	return best, bestDistance <= maxDistance
+}
+
+// longNames returns long keys available in the index and its parents,
+// including --no-<name> keys and --help if it's not redefined
+func (index *fieldsIndex) longNames() []string {
+	names := make([]string, 0)
+	for current := index; current != nil; current = current.parent {
+		for _, entry := range current.entries {
+			if entry.m.longName == "" {
+				continue
+			}
+			names = append(names, entry.m.longName)
+			if negated, ok := current.negatedFields[entry.m.negatedName()]; ok && negated == entry {
+				names = append(names, entry.m.negatedName())
+			}
+		}
+	}
+	if _, ok := index.lookupLongName(helpLongName); !ok {
+		names = append(names, helpLongName)
+	}
+	return names
+}
+
+// suggestLongKey returns the known long key closest to the unknown one
+func suggestLongKey(index *fieldsIndex, key string) string {
+	suggestion, _ := suggest(key, index.longNames())
+	return suggestion
+}
+
+// suggestShortKey returns the key for the unknown short key of the group.
+// Any two short keys are one edit away, so only keys differing in case are
+// suggested. If the group looks like a long key with single hyphen (-limit),
+// the long key is suggested. Groups of flags like -vx are too short to be
+// compared with long keys reliably, so they must be at most one edit away.
+func suggestShortKey(index *fieldsIndex, group string, key string) string {
+	if utf8.RuneCountInString(group) > 3 {
+		for _, name := range index.longNames() {
+			if levenshtein("-"+group, name) <= 1 {
+				return name
+			}
+		}
+	}
+	for current := index; current != nil; current = current.parent {
+		for _, entry := range current.entries {
+			if entry.m.shortName != "" && strings.EqualFold(entry.m.shortName, key) {
+				return entry.m.shortName
+			}
+		}
+	}
+	return ""
+}
+
+// suggestCommand returns the command of the index closest to the value
+func suggestCommand(index *fieldsIndex, value string) string {
+	names := make([]string, 0, len(index.commandEntries))
+	for _, command := range index.commandEntries {
+		names = append(names, command.m.commandName)
+	}
+	suggestion, _ := suggest(value, names)
+	return suggestion
+}
+
+// suggestChoice returns the choice closest to the value. With ignorecase both
+// sides are compared in lower case, the choice is returned as it's declared.
+func suggestChoice(meta fieldMeta, value string) (string, bool) {
+	if !meta.ignoreCase {
+		return suggest(value, meta.choices)
+	}
+
+	folded := make([]string, 0, len(meta.choices))
+	for _, choice := range meta.choices {
+		folded = append(folded, strings.ToLower(choice))
+	}
+	suggestion, ok := suggest(strings.ToLower(value), folded)
+	if !ok {
+		return "", false
+	}
+	return meta.choices[slices.Index(folded, suggestion)], true
+}
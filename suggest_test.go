@@ -0,0 +1,84 @@
+package argoparser
+
+import "testing"
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "", b: "", want: 0},
+		{a: "abc", b: "", want: 3},
+		{a: "limit", b: "lmit", want: 1},
+		{a: "kitten", b: "sitting", want: 3},
+		{a: "🍏🍎", b: "🍎🍏", want: 2},
+	}
+
+	for _, test := range tests {
+		got := levenshtein(test.a, test.b)
+		if got != test.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	candidates := []string{"testing", "staging", "production"}
+
+	tests := []struct {
+		value string
+		want  string
+		ok    bool
+	}{
+		{value: "prodution", want: "production", ok: true},
+		{value: "stagin", want: "staging", ok: true},
+		{value: "tst", want: "", ok: false},
+		{value: "local", want: "", ok: false},
+	}
+
+	for _, test := range tests {
+		got, ok := suggest(test.value, candidates)
+		if ok != test.ok || (ok && got != test.want) {
+			t.Errorf("suggest(%q) = %q, %v, want %q, %v", test.value, got, ok, test.want, test.ok)
+		}
+	}
+}
+
+type testSuggestGetCmd struct {
+	UserID int `arg:"--user-id,-u"`
+}
+
+type testSuggestArgs struct {
+	Limit   int                `arg:"--limit,-l"`
+	Verbose bool               `arg:"--verbose,-v"`
+	Color   bool               `arg:"--color,negatable"`
+	Get     *testSuggestGetCmd `cmd:"get"`
+	Delete  *testSuggestGetCmd `cmd:"delete"`
+}
+
+func TestUnknownKeySuggestions(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: "--lmit 10", want: "unknown long key: --lmit, did you mean --limit?"},
+		{input: "--no-colour", want: "unknown long key: --no-colour, did you mean --no-color?"},
+		{input: "--hepl", want: "unknown long key: --hepl, did you mean --help?"},
+		{input: "--output", want: "unknown long key: --output"},
+		{input: "-verbse", want: "unknown short key: -e, did you mean --verbose?"},
+		{input: "-V", want: "unknown short key: -V, did you mean -v?"},
+		{input: "-vx", want: "unknown short key: -x"},
+		{input: "gte", want: "unexpected positional parameter: gte, did you mean get?"},
+		{input: "list", want: "unexpected positional parameter: list"},
+		{input: "get --user-di 1", want: "unknown long key: --user-di, did you mean --user-id?"},
+		{input: "get --limt 1", want: "unknown long key: --limt, did you mean --limit?"},
+	}
+
+	parser := Parser{}
+	for _, test := range tests {
+		err := parser.ParseString(test.input, &testSuggestArgs{})
+		if err == nil || err.Error() != test.want {
+			t.Errorf("ParseString(%q) = %v, want %q", test.input, err, test.want)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package argoparser
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// Value is the interface for custom argument types. Set is called for every
+// passed value of the argument, String is used to render default value in help.
+//
+// Like for flag.Value, Set of the field is called on the same value every
+// time, so it may accumulate values. For slices of Value every item is set
+// separately.
+type Value interface {
+	Set(string) error
+	String() string
+}
+
+// Converter converts raw value of the argument to the value of registered type
+type Converter func(string) (any, error)
+
+var (
+	valueType           = reflect.TypeOf((*Value)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+	durationType = reflect.TypeOf(time.Duration(0))
+	timeType     = reflect.TypeOf(time.Time{})
+	urlType      = reflect.TypeOf(url.URL{})
+	urlPtrType   = reflect.TypeOf(&url.URL{})
+	fileModeType = reflect.TypeOf(os.FileMode(0))
+)
+
+// RegisterType registers converter for the type. Registered converters have
+// priority over Value and encoding.TextUnmarshaler implementations and
+// built-in types. Converter must return value assignable to the type.
+func (p *Parser) RegisterType(t reflect.Type, converter Converter) {
+	if p.converters == nil {
+		p.converters = make(map[reflect.Type]Converter)
+	}
+	p.converters[t] = converter
+}
+
+// isCustomType checks if the type is converted as a whole, even if it's a slice
+func (p *Parser) isCustomType(t reflect.Type) bool {
+	return p.hasConverter(t) || implementsSetter(t)
+}
+
+// hasConverter checks if the type is converted by registered or built-in converter
+func (p *Parser) hasConverter(t reflect.Type) bool {
+	if _, ok := p.converters[t]; ok {
+		return true
+	}
+	_, ok := builtinTypeConverter(t)
+	return ok
+}
+
+func implementsSetter(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	ptr := reflect.PointerTo(t)
+	return ptr.Implements(valueType) || ptr.Implements(textUnmarshalerType)
+}
+
+// builtinTypeConverter returns converter for standard library types which
+// are not converted correctly by their kind or by TextUnmarshaler
+func builtinTypeConverter(t reflect.Type) (func(value string, meta fieldMeta) (any, error), bool) {
+	switch t {
+	case durationType:
+		return func(value string, _ fieldMeta) (any, error) {
+			return time.ParseDuration(value)
+		}, true
+	case timeType:
+		return func(value string, meta fieldMeta) (any, error) {
+			layout := meta.layout
+			if layout == "" {
+				layout = time.RFC3339
+			}
+			return time.Parse(layout, value)
+		}, true
+	case urlType:
+		return func(value string, _ fieldMeta) (any, error) {
+			parsed, err := url.Parse(value)
+			if err != nil {
+				return nil, err
+			}
+			return *parsed, nil
+		}, true
+	case urlPtrType:
+		return func(value string, _ fieldMeta) (any, error) {
+			return url.Parse(value)
+		}, true
+	case fileModeType:
+		return func(value string, _ fieldMeta) (any, error) {
+			// file modes are usually written in octal: 0644
+			parsed, err := strconv.ParseUint(value, 8, 32)
+			if err != nil {
+				return nil, err
+			}
+			return os.FileMode(parsed), nil
+		}, true
+	}
+	return nil, false
+}
+
+// setValue converts the raw value and stores it to the target, which must be
+// addressable. Meta of the field provides options of conversion like layout.
+// Unsupported types are reported with DefinitionError without the field.
+func (p *Parser) setValue(target reflect.Value, value string, meta fieldMeta) error {
+	t := target.Type()
+
+	if converter, ok := p.converters[t]; ok {
+		converted, err := converter(value)
+		if err != nil {
+			return fmt.Errorf("cannot convert %q to %s: %w", value, t, err)
+		}
+		cv := reflect.ValueOf(converted)
+		if !cv.IsValid() || !cv.Type().AssignableTo(t) {
+			return &DefinitionError{Err: fmt.Errorf("converter for %s returned %T", t, converted)}
+		}
+		target.Set(cv)
+		return nil
+	}
+
+	if converter, ok := builtinTypeConverter(t); ok {
+		converted, err := converter(value, meta)
+		if err != nil {
+			return fmt.Errorf("cannot convert %q to %s: %w", value, t, err)
+		}
+		target.Set(reflect.ValueOf(converted))
+		return nil
+	}
+
+	// pointer to the type with converter is filled by that converter, even if
+	// the type implements TextUnmarshaler: *time.Time must respect layout tag
+	if t.Kind() == reflect.Ptr && p.hasConverter(t.Elem()) {
+		return p.setPointer(target, value, meta)
+	}
+
+	if implementsSetter(t) {
+		if t.Kind() == reflect.Ptr {
+			if target.IsNil() {
+				target.Set(reflect.New(t.Elem()))
+			}
+		} else {
+			target = target.Addr()
+		}
+
+		var err error
+		if setter, ok := target.Interface().(Value); ok {
+			err = setter.Set(value)
+		} else {
+			err = target.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+		}
+		if err != nil {
+			return fmt.Errorf("cannot convert %q to %s: %w", value, t, err)
+		}
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(value, 10, t.Bits())
+		if err != nil {
+			return numError(t, value, err)
+		}
+		target.SetInt(parsed)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parsed, err := strconv.ParseUint(value, 10, t.Bits())
+		if err != nil {
+			return numError(t, value, err)
+		}
+		target.SetUint(parsed)
+	case reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(value, t.Bits())
+		if err != nil {
+			return numError(t, value, err)
+		}
+		target.SetFloat(parsed)
+	case reflect.Complex64, reflect.Complex128:
+		parsed, err := strconv.ParseComplex(value, t.Bits())
+		if err != nil {
+			return numError(t, value, err)
+		}
+		target.SetComplex(parsed)
+	case reflect.String:
+		target.SetString(value)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a valid bool", value)
+		}
+		target.SetBool(parsed)
+	case reflect.Ptr:
+		return p.setPointer(target, value, meta)
+	default:
+		return &DefinitionError{Err: fmt.Errorf("unsupported type: %s", t)}
+	}
+
+	return nil
+}
+
+func numError(t reflect.Type, value string, err error) error {
+	if errors.Is(err, strconv.ErrRange) {
+		return fmt.Errorf("%s is out of range for %s", value, t.Kind())
+	}
+	return fmt.Errorf("%q is not a valid %s", value, t.Kind())
+}
+
+// setPointer converts the value to the type the pointer points to. The pointer
+// stays nil until the value is passed.
+func (p *Parser) setPointer(target reflect.Value, value string, meta fieldMeta) error {
+	ptr := reflect.New(target.Type().Elem())
+	if err := p.setValue(ptr.Elem(), value, meta); err != nil {
+		return err
+	}
+	target.Set(ptr)
+	return nil
+}
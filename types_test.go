@@ -0,0 +1,507 @@
+package argoparser
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/netip"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testLevel implements Value
+type testLevel int
+
+func (l *testLevel) Set(value string) error {
+	switch value {
+	case "low":
+		*l = 1
+	case "high":
+		*l = 2
+	default:
+		return errors.New("unknown level")
+	}
+	return nil
+}
+
+func (l *testLevel) String() string {
+	return [...]string{"none", "low", "high"}[*l]
+}
+
+// testList implements Value accumulating passed values
+type testList []string
+
+func (l *testList) Set(value string) error {
+	*l = append(*l, strings.ToUpper(value))
+	return nil
+}
+
+func (l *testList) String() string {
+	return strings.Join(*l, ";")
+}
+
+// testPoint implements encoding.TextUnmarshaler
+type testPoint struct {
+	X, Y int
+}
+
+func (p *testPoint) UnmarshalText(text []byte) error {
+	_, err := fmt.Sscanf(string(text), "%d:%d", &p.X, &p.Y)
+	return err
+}
+
+type testMoney struct {
+	Cents int
+}
+
+func parseTestMoney(value string) (any, error) {
+	units, cents, _ := strings.Cut(value, ".")
+	u, err := strconv.Atoi(units)
+	if err != nil {
+		return nil, err
+	}
+	c, err := strconv.Atoi(cents)
+	if err != nil {
+		return nil, err
+	}
+	return testMoney{Cents: u*100 + c}, nil
+}
+
+func TestCustomTypes(t *testing.T) {
+	tc := []TestCase{
+		{
+			Name:  "Value, TextUnmarshaler and slices of them",
+			Input: "--level high --levels low --levels high --point 1:2 --points 3:4 --points 5:6 --ptr 7:8",
+			Result: struct {
+				Level  testLevel   `arg:"--level"`
+				Levels []testLevel `arg:"--levels"`
+				Point  testPoint   `arg:"--point"`
+				Points []testPoint `arg:"--points"`
+				Ptr    *testPoint  `arg:"--ptr"`
+			}{
+				Level:  2,
+				Levels: []testLevel{1, 2},
+				Point:  testPoint{1, 2},
+				Points: []testPoint{{3, 4}, {5, 6}},
+				Ptr:    &testPoint{7, 8},
+			},
+		},
+		{
+			Name:  "Slice type implementing Value is a single value",
+			Input: "--list a --list b",
+			Result: struct {
+				List testList `arg:"--list"`
+			}{
+				List: testList{"A", "B"},
+			},
+		},
+		{
+			Name:  "Positional slice type implementing Value is not positionals default",
+			Input: "a b",
+			Result: struct {
+				First  testList `arg:"positional"`
+				Second testList `arg:"positional"`
+			}{
+				First:  testList{"A"},
+				Second: testList{"B"},
+			},
+		},
+		{
+			Name:  "Invalid value for Value",
+			Input: "--level medium",
+			Result: struct {
+				Level testLevel `arg:"--level"`
+			}{},
+			ShouldReturnError: true,
+		},
+		{
+			Name:  "Invalid value for TextUnmarshaler",
+			Input: "--point 1",
+			Result: struct {
+				Point testPoint `arg:"--point"`
+			}{},
+			ShouldReturnError: true,
+		},
+		{
+			Name:  "Named built-in types",
+			Input: "--level 5",
+			Result: struct {
+				Level testNamedInt `arg:"--level"`
+			}{
+				Level: 5,
+			},
+		},
+	}
+
+	for _, testCase := range tc {
+		impl(t, testCase, false)
+	}
+}
+
+type testNamedInt int
+
+func TestRegisterType(t *testing.T) {
+	type Args struct {
+		Price  testMoney   `arg:"--price"`
+		Prices []testMoney `arg:"--prices"`
+	}
+
+	parser := Parser{}
+	parser.RegisterType(reflect.TypeOf(testMoney{}), parseTestMoney)
+
+	result := Args{}
+	if err := parser.ParseString("--price 1.05 --prices 2.10 --prices 0.01", &result); err != nil {
+		t.Fatalf("ParseString failed: %s", err)
+	}
+
+	expected := Args{
+		Price:  testMoney{105},
+		Prices: []testMoney{{210}, {1}},
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatalf("expected %v, got %v", expected, result)
+	}
+
+	if err := parser.ParseString("--price abc", &result); err == nil {
+		t.Fatal("expected error for invalid value")
+	}
+
+	parser.RegisterType(reflect.TypeOf(testMoney{}), func(string) (any, error) {
+		return 5, nil
+	})
+	if err := parser.ParseString("--price 1.00", &result); err == nil {
+		t.Fatal("expected error for converter returning wrong type")
+	}
+}
+
+func TestCustomTypeDefaultInHelp(t *testing.T) {
+	args := struct {
+		Level testLevel `arg:"--level"`
+	}{
+		Level: 2,
+	}
+
+	parser := Parser{Name: "app"}
+	builder := &strings.Builder{}
+	if err := parser.WriteHelp(builder, &args); err != nil {
+		t.Fatalf("WriteHelp failed: %s", err)
+	}
+	if !strings.Contains(builder.String(), "(default: high)") {
+		t.Fatalf("expected default rendered with String method:\n%s", builder.String())
+	}
+}
+
+func TestNumericTypes(t *testing.T) {
+	tc := []TestCase{
+		{
+			Name:  "Signed, unsigned, float and complex types",
+			Input: "--i8 -128 --i16 32767 --i32 -5 --i64 9223372036854775807 --u 7 --u8 255 --u16 1 --u32 2 --u64 18446744073709551615 --f32 1.5 --f64 -2.25e3 --c64 1+2i --c128 -3i --f64s 1 --f64s 0.5",
+			Result: struct {
+				I8   int8       `arg:"--i8"`
+				I16  int16      `arg:"--i16"`
+				I32  int32      `arg:"--i32"`
+				I64  int64      `arg:"--i64"`
+				U    uint       `arg:"--u"`
+				U8   uint8      `arg:"--u8"`
+				U16  uint16     `arg:"--u16"`
+				U32  uint32     `arg:"--u32"`
+				U64  uint64     `arg:"--u64"`
+				F32  float32    `arg:"--f32"`
+				F64  float64    `arg:"--f64"`
+				C64  complex64  `arg:"--c64"`
+				C128 complex128 `arg:"--c128"`
+				F64s []float64  `arg:"--f64s"`
+			}{
+				I8: -128, I16: 32767, I32: -5, I64: 9223372036854775807,
+				U: 7, U8: 255, U16: 1, U32: 2, U64: 18446744073709551615,
+				F32: 1.5, F64: -2250, C64: 1 + 2i, C128: -3i, F64s: []float64{1, 0.5},
+			},
+		},
+		{
+			Name:  "Negative value for unsigned",
+			Input: "--u -1",
+			Result: struct {
+				U uint `arg:"--u"`
+			}{},
+			ShouldReturnError: true,
+		},
+		{
+			Name:  "Bools as positional arguments",
+			Input: "true 0",
+			Result: struct {
+				A bool `arg:"positional"`
+				B bool `arg:"positional"`
+			}{
+				A: true, B: false,
+			},
+		},
+		{
+			Name:  "Explicit bool values",
+			Input: `--a=false --b=true --c="false" -d"false" --e false`,
+			Result: struct {
+				A bool     `arg:"--a"`
+				B bool     `arg:"--b"`
+				C bool     `arg:"--c"`
+				D bool     `arg:"-d"`
+				E bool     `arg:"--e"`
+				F []string `arg:"positional"`
+			}{
+				A: false, B: true, C: false, D: false, E: true, F: []string{"false"},
+			},
+		},
+		{
+			Name:  "Invalid explicit bool value",
+			Input: "--a=maybe",
+			Result: struct {
+				A bool `arg:"--a"`
+			}{},
+			ShouldReturnError: true,
+		},
+	}
+
+	for _, testCase := range tc {
+		impl(t, testCase, false)
+	}
+
+	t.Run("Overflow errors name the field", func(t *testing.T) {
+		result := struct {
+			Small int8 `arg:"--small"`
+		}{}
+
+		parser := Parser{}
+		err := parser.ParseString("--small 300", &result)
+		if err == nil || err.Error() != "invalid value for --small: 300 is out of range for int8" {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		err = parser.ParseString("--small abc", &result)
+		if err == nil || err.Error() != `invalid value for --small: "abc" is not a valid int8` {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Big numbers", func(t *testing.T) {
+		result := struct {
+			Int   *big.Int   `arg:"--int"`
+			Float *big.Float `arg:"--float"`
+		}{}
+
+		parser := Parser{}
+		if err := parser.ParseString("--int 123456789012345678901234567890 --float 1.5", &result); err != nil {
+			t.Fatalf("ParseString failed: %s", err)
+		}
+		if result.Int.String() != "123456789012345678901234567890" || result.Float.String() != "1.5" {
+			t.Fatalf("unexpected result: %v %v", result.Int, result.Float)
+		}
+
+		if err := parser.ParseString("--int 1.5", &result); err == nil {
+			t.Fatal("expected error for invalid big.Int")
+		}
+	})
+
+	t.Run("Bool values with BoolValues option", func(t *testing.T) {
+		result := struct {
+			JSON  bool     `arg:"--json,-j"`
+			Color bool     `arg:"--color"`
+			Rest  []string `arg:"positional"`
+		}{}
+
+		parser := Parser{BoolValues: true}
+		if err := parser.ParseString("--json false --color pos", &result); err != nil {
+			t.Fatalf("ParseString failed: %s", err)
+		}
+		if result.JSON || !result.Color || !reflect.DeepEqual(result.Rest, []string{"pos"}) {
+			t.Fatalf("unexpected result: %+v", result)
+		}
+
+		if err := parser.ParseString("-j 0", &result); err != nil {
+			t.Fatalf("ParseString failed: %s", err)
+		}
+		if result.JSON {
+			t.Fatalf("expected false, got %v", result.JSON)
+		}
+	})
+}
+
+func TestStdlibTypes(t *testing.T) {
+	type Args struct {
+		Timeout  time.Duration   `arg:"--timeout"`
+		Retries  []time.Duration `arg:"--retry"`
+		Since    time.Time       `arg:"--since"`
+		Day      time.Time       `arg:"--day" layout:"2006-01-02"`
+		IP       net.IP          `arg:"--ip"`
+		IPs      []net.IP        `arg:"--ips"`
+		Addr     netip.Addr      `arg:"--addr"`
+		Prefix   netip.Prefix    `arg:"--prefix"`
+		URL      *url.URL        `arg:"--url"`
+		URLs     []url.URL       `arg:"--urls"`
+		Pattern  *regexp.Regexp  `arg:"--pattern"`
+		FileMode os.FileMode     `arg:"--mode"`
+	}
+
+	input := "--timeout 1m30s --retry 1s --retry 2s --since 2024-01-02T03:04:05Z --day 2024-05-06 " +
+		"--ip 10.0.0.1 --ips ::1 --ips 127.0.0.1 --addr 192.168.0.1 --prefix 10.0.0.0/8 " +
+		"--url https://example.com/path?q=1 --urls http://a --pattern ^[a-z]+$ --mode 0644"
+
+	result := Args{}
+	parser := Parser{}
+	if err := parser.ParseString(input, &result); err != nil {
+		t.Fatalf("ParseString failed: %s", err)
+	}
+
+	if result.Timeout != 90*time.Second {
+		t.Errorf("unexpected timeout: %s", result.Timeout)
+	}
+	if !reflect.DeepEqual(result.Retries, []time.Duration{time.Second, 2 * time.Second}) {
+		t.Errorf("unexpected retries: %v", result.Retries)
+	}
+	if !result.Since.Equal(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("unexpected since: %s", result.Since)
+	}
+	if !result.Day.Equal(time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected day: %s", result.Day)
+	}
+	if !result.IP.Equal(net.ParseIP("10.0.0.1")) || len(result.IPs) != 2 || !result.IPs[1].Equal(net.ParseIP("127.0.0.1")) {
+		t.Errorf("unexpected ips: %v %v", result.IP, result.IPs)
+	}
+	if result.Addr != netip.MustParseAddr("192.168.0.1") || result.Prefix != netip.MustParsePrefix("10.0.0.0/8") {
+		t.Errorf("unexpected addr or prefix: %v %v", result.Addr, result.Prefix)
+	}
+	if result.URL.Host != "example.com" || result.URL.Query().Get("q") != "1" || len(result.URLs) != 1 || result.URLs[0].Host != "a" {
+		t.Errorf("unexpected urls: %v %v", result.URL, result.URLs)
+	}
+	if !result.Pattern.MatchString("abc") || result.Pattern.MatchString("ABC") {
+		t.Errorf("unexpected pattern: %v", result.Pattern)
+	}
+	if result.FileMode != 0644 {
+		t.Errorf("unexpected mode: %o", result.FileMode)
+	}
+
+	invalid := []string{
+		"--timeout 5",
+		"--since 2024-01-02",
+		"--day 02.01.2024",
+		"--ip 10.0.0",
+		"--addr host",
+		"--prefix 10.0.0.0",
+		"--url ://",
+		"--pattern [a-z",
+		"--mode 0999",
+	}
+	for _, input := range invalid {
+		if err := parser.ParseString(input, &Args{}); err == nil {
+			t.Errorf("expected error for %q", input)
+		}
+	}
+}
+
+func TestPointerTypes(t *testing.T) {
+	type Args struct {
+		UserID  *int           `arg:"--user-id"`
+		Name    *string        `arg:"--name"`
+		JSON    *bool          `arg:"--json,-j"`
+		Color   *bool          `arg:"--color"`
+		Timeout *time.Duration `arg:"--timeout"`
+		Level   *testLevel     `arg:"--level"`
+		IDs     []*int         `arg:"--id"`
+		Pos     *int           `arg:"positional"`
+	}
+
+	t.Run("Absent values stay nil", func(t *testing.T) {
+		result := Args{}
+		parser := Parser{}
+		if err := parser.ParseString("", &result); err != nil {
+			t.Fatalf("ParseString failed: %s", err)
+		}
+		if result.UserID != nil || result.Name != nil || result.JSON != nil || result.Timeout != nil || result.Level != nil || result.Pos != nil {
+			t.Fatalf("expected nil pointers, got %+v", result)
+		}
+	})
+
+	t.Run("Zero values are distinguished from absent", func(t *testing.T) {
+		result := Args{}
+		parser := Parser{}
+		input := "--user-id 0 --name '' -j --color=false --timeout 0s --level low --id 1 --id 0 5"
+		if err := parser.ParseString(input, &result); err != nil {
+			t.Fatalf("ParseString failed: %s", err)
+		}
+		if result.UserID == nil || *result.UserID != 0 {
+			t.Errorf("unexpected user id: %v", result.UserID)
+		}
+		if result.Name == nil || *result.Name != "" {
+			t.Errorf("unexpected name: %v", result.Name)
+		}
+		if result.JSON == nil || !*result.JSON {
+			t.Errorf("unexpected json: %v", result.JSON)
+		}
+		if result.Color == nil || *result.Color {
+			t.Errorf("unexpected color: %v", result.Color)
+		}
+		if result.Timeout == nil || *result.Timeout != 0 {
+			t.Errorf("unexpected timeout: %v", result.Timeout)
+		}
+		if result.Level == nil || *result.Level != 1 {
+			t.Errorf("unexpected level: %v", result.Level)
+		}
+		if len(result.IDs) != 2 || *result.IDs[0] != 1 || *result.IDs[1] != 0 {
+			t.Errorf("unexpected ids: %v", result.IDs)
+		}
+		if result.Pos == nil || *result.Pos != 5 {
+			t.Errorf("unexpected positional: %v", result.Pos)
+		}
+	})
+
+	t.Run("Invalid value", func(t *testing.T) {
+		result := Args{}
+		parser := Parser{}
+		if err := parser.ParseString("--user-id abc", &result); err == nil {
+			t.Fatal("expected error for invalid value")
+		}
+		if result.UserID != nil {
+			t.Fatalf("expected nil pointer after error, got %v", *result.UserID)
+		}
+	})
+
+	t.Run("Pointers to time respect layout", func(t *testing.T) {
+		result := struct {
+			Since *time.Time   `arg:"--since" layout:"2006-01-02"`
+			Days  []*time.Time `arg:"--day" layout:"2006-01-02"`
+		}{}
+		parser := Parser{}
+		if err := parser.ParseString("--since 2024-01-02 --day 2024-03-04", &result); err != nil {
+			t.Fatalf("ParseString failed: %s", err)
+		}
+		since := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+		if result.Since == nil || !result.Since.Equal(since) {
+			t.Errorf("unexpected since: %v", result.Since)
+		}
+		day := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+		if len(result.Days) != 1 || !result.Days[0].Equal(day) {
+			t.Errorf("unexpected days: %v", result.Days)
+		}
+	})
+
+	t.Run("Pre-filled pointer is shown in help", func(t *testing.T) {
+		limit := 10
+		args := struct {
+			Limit *int `arg:"--limit"`
+		}{
+			Limit: &limit,
+		}
+		parser := Parser{Name: "app"}
+		builder := &strings.Builder{}
+		if err := parser.WriteHelp(builder, &args); err != nil {
+			t.Fatalf("WriteHelp failed: %s", err)
+		}
+		if !strings.Contains(builder.String(), "--limit   int   (default: 10)") {
+			t.Fatalf("unexpected help:\n%s", builder.String())
+		}
+	})
+}